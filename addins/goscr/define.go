@@ -22,5 +22,6 @@ package goscr
 import "git.golaxy.org/core/define"
 
 var (
-	AddIn = define.ServiceAddIn(newScript)
+	AddIn      = define.ServiceAddIn(newScript)
+	AdminAddIn = define.RuntimeAddIn(newScriptAdmin)
 )
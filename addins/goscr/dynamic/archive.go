@@ -0,0 +1,167 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dynamic
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// extractTarGzip 解压tar.gz数据至目标路径
+func extractTarGzip(dst afero.Fs, root string, data []byte) error {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	return extractTar(dst, root, "", gzipReader)
+}
+
+// extractTar 解压tar数据至目标路径，strip为需要去除的路径前缀，不在前缀下的文件将被忽略
+func extractTar(dst afero.Fs, root, strip string, r io.Reader) error {
+	tarReader := tar.NewReader(r)
+
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		filePath, ok := archiveFilePath(root, strip, header.Name)
+		if !ok {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := dst.MkdirAll(filePath, os.ModePerm); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := dst.MkdirAll(path.Dir(filePath), os.ModePerm); err != nil {
+				return err
+			}
+
+			err := func() error {
+				dstFile, err := dst.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+				if err != nil {
+					return err
+				}
+				defer dstFile.Close()
+
+				if _, err := io.Copy(dstFile, tarReader); err != nil {
+					return err
+				}
+
+				return nil
+			}()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// extractZip 解压zip数据至目标路径
+func extractZip(dst afero.Fs, root string, data []byte) error {
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+
+	for _, zipFile := range zipReader.File {
+		filePath, ok := archiveFilePath(root, "", zipFile.Name)
+		if !ok {
+			continue
+		}
+
+		if zipFile.FileInfo().IsDir() {
+			if err := dst.MkdirAll(filePath, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := dst.MkdirAll(path.Dir(filePath), os.ModePerm); err != nil {
+			return err
+		}
+
+		err := func() error {
+			zipFileReader, err := zipFile.Open()
+			if err != nil {
+				return err
+			}
+			defer zipFileReader.Close()
+
+			dstFile, err := dst.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+			if err != nil {
+				return err
+			}
+			defer dstFile.Close()
+
+			if _, err := io.Copy(dstFile, zipFileReader); err != nil {
+				return err
+			}
+
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// archiveFilePath 打包文件内的路径转换为目标路径，忽略越界的路径
+func archiveFilePath(root, strip, name string) (string, bool) {
+	name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", false
+	}
+
+	if strip != "" {
+		strip = path.Clean(strip)
+		if name != strip && !strings.HasPrefix(name, strip+"/") {
+			return "", false
+		}
+		name = strings.TrimPrefix(strings.TrimPrefix(name, strip), "/")
+		if name == "" {
+			name = "."
+		}
+	}
+
+	return path.Join(root, name), true
+}
@@ -0,0 +1,234 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dynamic
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/ast"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrIncrementalConflict 增量编译时，重新编译的包导入了先加载的项目中已复用的包，需要全量编译
+var ErrIncrementalConflict = errors.New("incremental compile conflict")
+
+// _ParsedFile 已解析的脚本文件
+type _ParsedFile struct {
+	hash string
+	file *ast.File
+}
+
+// _ParseCache 脚本文件解析缓存，记录已解析的文件、包源码哈希与包导入关系
+type _ParseCache struct {
+	files    map[string]*_ParsedFile
+	pkgFiles map[string][]string
+	hashes   map[string]string
+	imports  map[string][]string
+}
+
+func newParseCache() *_ParseCache {
+	return &_ParseCache{
+		files:    map[string]*_ParsedFile{},
+		pkgFiles: map[string][]string{},
+		hashes:   map[string]string{},
+		imports:  map[string][]string{},
+	}
+}
+
+func (cache *_ParseCache) add(pkgPath, filePath string, parsed *_ParsedFile) {
+	cache.files[filePath] = parsed
+	cache.pkgFiles[pkgPath] = append(cache.pkgFiles[pkgPath], filePath)
+}
+
+// seal 计算脚本路径下所有包的源码哈希与导入关系
+func (cache *_ParseCache) seal(scriptPath string) {
+	for pkgPath, filePaths := range cache.pkgFiles {
+		if !inScriptPath(pkgPath, scriptPath) {
+			continue
+		}
+
+		slices.Sort(filePaths)
+
+		h := sha1.New()
+		var imports []string
+
+		for _, filePath := range filePaths {
+			parsed := cache.files[filePath]
+			h.Write([]byte(filePath))
+			h.Write([]byte(parsed.hash))
+
+			for _, spec := range parsed.file.Imports {
+				if imp, err := strconv.Unquote(spec.Path.Value); err == nil && !slices.Contains(imports, imp) {
+					imports = append(imports, imp)
+				}
+			}
+		}
+
+		cache.hashes[pkgPath] = hex.EncodeToString(h.Sum(nil))
+		cache.imports[pkgPath] = imports
+	}
+}
+
+func hashFileData(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// _Incremental 增量编译状态，只保留上一个解决方案的解析结果与已编译脚本，不引用上一个解决方案
+type _Incremental struct {
+	prevFiles    map[string]*_ParsedFile
+	prevHashes   map[string]string
+	prevLib      ScriptLib
+	changed      map[string]struct{}
+	manifestOnly bool
+}
+
+// Incremental 基于上一个解决方案增量编译，需要在Load之前调用。
+// 源码变化的包与通过导入关系（无论方向）与它相连的所有脚本包一起使用新的解释器重新编译，其余包复用上一个解决方案的编译结果，内容未变化的文件复用语法树。
+// 复用的包与重新编译的包之间没有导入关系，不会出现同一个包在两个解释器中各有一份包级状态的情况，但复用的包仍引用上一个解决方案的解释器。
+// 后加载的项目中重新编译的包导入了先加载的项目中已复用的包时，Load返回ErrIncrementalConflict，需要不使用增量编译重新加载。
+// changedPaths为已知变化的脚本文件路径（包含包根路径与脚本根路径），未提供时根据源码哈希判断。
+func (s *Solution) Incremental(prev *Solution, changedPaths ...string) {
+	s.incremental = newIncremental(prev, s.pkgRoot, false, changedPaths)
+}
+
+// IncrementalManifest 与Incremental相同，但只有使用远程清单（ManifestURL）的项目复用上一个解决方案的编译结果，其他项目全部重新编译
+func (s *Solution) IncrementalManifest(prev *Solution, changedPaths ...string) {
+	s.incremental = newIncremental(prev, s.pkgRoot, true, changedPaths)
+}
+
+func newIncremental(prev *Solution, pkgRoot string, manifestOnly bool, changedPaths []string) *_Incremental {
+	if prev == nil || prev.pkgRoot != pkgRoot || prev.parseCache == nil {
+		return nil
+	}
+
+	inc := &_Incremental{
+		prevFiles:    prev.parseCache.files,
+		prevHashes:   prev.parseCache.hashes,
+		prevLib:      prev.scriptLib,
+		changed:      map[string]struct{}{},
+		manifestOnly: manifestOnly,
+	}
+
+	for _, changedPath := range changedPaths {
+		inc.changed[path.Dir(path.Clean(changedPath))] = struct{}{}
+	}
+
+	return inc
+}
+
+// ReusedPackages 增量编译时复用的脚本包
+func (s *Solution) ReusedPackages() []string {
+	return slices.Clone(s.reused)
+}
+
+func (inc *_Incremental) files() map[string]*_ParsedFile {
+	if inc == nil {
+		return nil
+	}
+	return inc.prevFiles
+}
+
+// reusable 获取项目脚本路径下可以复用的已编译脚本包，reused为先加载的项目中已复用的包
+func (inc *_Incremental) reusable(cache *_ParseCache, project *Project, scriptPath string, reused []string) (ScriptLib, error) {
+	if inc == nil {
+		return nil, nil
+	}
+
+	// 不复用的项目全部重新编译，仍需检查是否导入了先加载的项目中已复用的包
+	rebuildAll := inc.manifestOnly && project.ManifestURL == ""
+
+	isScriptPkg := func(pkgPath string) bool {
+		_, ok := cache.hashes[pkgPath]
+		return ok
+	}
+
+	// 导入关系按无向图处理，重新编译的包所在的连通分量整体重新编译
+	edges := map[string][]string{}
+	for pkgPath, imports := range cache.imports {
+		for _, imp := range imports {
+			if !isScriptPkg(imp) {
+				continue
+			}
+			edges[pkgPath] = append(edges[pkgPath], imp)
+			edges[imp] = append(edges[imp], pkgPath)
+		}
+	}
+
+	rebuild := map[string]bool{}
+	var queue []string
+
+	for pkgPath, hash := range cache.hashes {
+		var seed bool
+
+		if inScriptPath(pkgPath, scriptPath) {
+			_, changed := inc.changed[pkgPath]
+			_, compiled := inc.prevLib[pkgPath]
+			seed = rebuildAll || changed || !compiled || hash != inc.prevHashes[pkgPath]
+		} else {
+			// 先加载的项目中重新编译的包
+			seed = !slices.Contains(reused, pkgPath)
+		}
+
+		if seed {
+			rebuild[pkgPath] = true
+			queue = append(queue, pkgPath)
+		}
+	}
+
+	for len(queue) > 0 {
+		pkgPath := queue[0]
+		queue = queue[1:]
+
+		for _, next := range edges[pkgPath] {
+			if rebuild[next] {
+				continue
+			}
+			if !inScriptPath(next, scriptPath) {
+				return nil, fmt.Errorf("%w: package %q imports reused package %q", ErrIncrementalConflict, pkgPath, next)
+			}
+			rebuild[next] = true
+			queue = append(queue, next)
+		}
+	}
+
+	reuse := ScriptLib{}
+
+	for pkgPath := range cache.hashes {
+		if !inScriptPath(pkgPath, scriptPath) || rebuild[pkgPath] {
+			continue
+		}
+		if scriptBundle, ok := inc.prevLib[pkgPath]; ok {
+			reuse[pkgPath] = scriptBundle
+		}
+	}
+
+	return reuse, nil
+}
+
+// inScriptPath 包路径是否在脚本路径下
+func inScriptPath(pkgPath, scriptPath string) bool {
+	return pkgPath == scriptPath || strings.HasPrefix(pkgPath, scriptPath+"/")
+}
@@ -0,0 +1,287 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dynamic
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pangdogs/yaegi/interp"
+	"github.com/spf13/afero"
+)
+
+// ErrSymbolPolicy 违反符号访问策略
+var ErrSymbolPolicy = errors.New("symbol policy violation")
+
+// SymbolPolicy 符号访问策略，用于沙箱化脚本，作用于导入解决方案的所有符号表与脚本代码
+//
+// 规则格式为"包路径"或"包路径.符号"，包路径以"/..."结尾时同时匹配所有子包，例如"os/exec"、"os.Exit"、"net/..."。
+type SymbolPolicy struct {
+	Allow []string // 允许访问的包或符号，为空表示不限制
+	Deny  []string // 禁止访问的包或符号，优先于Allow
+}
+
+// PolicyViolation 违反符号访问策略的代码位置
+type PolicyViolation struct {
+	Pos     token.Position // 代码位置
+	PkgPath string         // 包路径
+	Symbol  string         // 符号，为空表示导入包
+}
+
+// Error 错误信息
+func (v *PolicyViolation) Error() string {
+	if v.Symbol == "" {
+		return fmt.Sprintf("%s: import %q is not allowed by symbol policy", v.Pos, v.PkgPath)
+	}
+	return fmt.Sprintf("%s: %s.%s is not allowed by symbol policy", v.Pos, v.PkgPath, v.Symbol)
+}
+
+// Unwrap 解包错误
+func (v *PolicyViolation) Unwrap() error {
+	return ErrSymbolPolicy
+}
+
+type _PolicyRule struct {
+	pkgPath   string
+	recursive bool
+	symbol    string
+}
+
+func parsePolicyRule(rule string) _PolicyRule {
+	var r _PolicyRule
+
+	if idx := strings.LastIndex(rule, "."); idx > strings.LastIndex(rule, "/") {
+		if symbol := rule[idx+1:]; token.IsIdentifier(symbol) && token.IsExported(symbol) {
+			rule, r.symbol = rule[:idx], symbol
+		}
+	}
+
+	r.pkgPath, r.recursive = strings.CutSuffix(rule, "/...")
+	return r
+}
+
+func (r _PolicyRule) matchPkg(pkgPath string) bool {
+	if r.recursive {
+		return pkgPath == r.pkgPath || strings.HasPrefix(pkgPath, r.pkgPath+"/")
+	}
+	return pkgPath == r.pkgPath
+}
+
+func (r _PolicyRule) match(pkgPath, symbol string) bool {
+	return r.matchPkg(pkgPath) && (r.symbol == "" || r.symbol == symbol)
+}
+
+func matchPolicyRules(rules []string, fun func(r _PolicyRule) bool) bool {
+	return slices.ContainsFunc(rules, func(rule string) bool { return fun(parsePolicyRule(rule)) })
+}
+
+// AllowImport 是否允许导入包，导入后仍需检查访问的符号
+func (p *SymbolPolicy) AllowImport(pkgPath string) bool {
+	if p == nil {
+		return true
+	}
+	if matchPolicyRules(p.Deny, func(r _PolicyRule) bool { return r.symbol == "" && r.matchPkg(pkgPath) }) {
+		return false
+	}
+	return len(p.Allow) <= 0 || matchPolicyRules(p.Allow, func(r _PolicyRule) bool { return r.matchPkg(pkgPath) })
+}
+
+// AllowSymbol 是否允许访问符号
+func (p *SymbolPolicy) AllowSymbol(pkgPath, symbol string) bool {
+	if p == nil {
+		return true
+	}
+	if matchPolicyRules(p.Deny, func(r _PolicyRule) bool { return r.match(pkgPath, symbol) }) {
+		return false
+	}
+	return len(p.Allow) <= 0 || matchPolicyRules(p.Allow, func(r _PolicyRule) bool { return r.match(pkgPath, symbol) })
+}
+
+// allowDotImport 是否允许使用"."导入包，此时无法检查访问的符号，需要允许整个包
+func (p *SymbolPolicy) allowDotImport(pkgPath string) bool {
+	if p == nil {
+		return true
+	}
+	if matchPolicyRules(p.Deny, func(r _PolicyRule) bool { return r.matchPkg(pkgPath) }) {
+		return false
+	}
+	return len(p.Allow) <= 0 || matchPolicyRules(p.Allow, func(r _PolicyRule) bool { return r.symbol == "" && r.matchPkg(pkgPath) })
+}
+
+// filter 过滤符号表，符号表的键格式为"包路径/包名"
+func (p *SymbolPolicy) filter(symbols interp.Exports) interp.Exports {
+	if p == nil {
+		return symbols
+	}
+
+	filtered := interp.Exports{}
+
+	for key, pkgSymbols := range symbols {
+		pkgPath := path.Dir(key)
+		if !p.AllowImport(pkgPath) {
+			continue
+		}
+
+		filteredSymbols := map[string]reflect.Value{}
+		for symbol, v := range pkgSymbols {
+			// 接口包装类型以"_"开头，与接口类型使用相同规则
+			if p.AllowSymbol(pkgPath, strings.TrimPrefix(symbol, "_")) {
+				filteredSymbols[symbol] = v
+			}
+		}
+		filtered[key] = filteredSymbols
+	}
+
+	return filtered
+}
+
+// check 检查脚本代码，返回所有违反策略的位置，脚本代码包不受策略限制
+func (p *SymbolPolicy) check(codeFs *CodeFs, scriptPath string) error {
+	if p == nil {
+		return nil
+	}
+
+	scriptPath = path.Clean(scriptPath)
+	fset := token.NewFileSet()
+
+	var violations []error
+
+	err := afero.Walk(codeFs.AferoFs(), scriptPath, func(filePath string, fileInfo fs.FileInfo, err error) error {
+		if err != nil || fileInfo.IsDir() || filepath.Ext(fileInfo.Name()) != ".go" {
+			return nil
+		}
+
+		fileData, err := afero.ReadFile(codeFs.AferoFs(), filePath)
+		if err != nil {
+			return fmt.Errorf("read script file %q failed, %s", filePath, err)
+		}
+
+		file, err := parser.ParseFile(fset, filepath.ToSlash(filePath), fileData, 0)
+		if err != nil {
+			return fmt.Errorf("parse script file %q failed, %s", filePath, err)
+		}
+
+		violations = append(violations, p.checkFile(codeFs, fset, file)...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(violations...)
+}
+
+func (p *SymbolPolicy) checkFile(codeFs *CodeFs, fset *token.FileSet, file *ast.File) []error {
+	var violations []error
+	imports := map[string]string{}
+
+	for _, spec := range file.Imports {
+		pkgPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		if ok, _ := afero.DirExists(codeFs.AferoFs(), pkgPath); ok {
+			continue
+		}
+
+		var allowed bool
+		if spec.Name != nil && spec.Name.Name == "." {
+			allowed = p.allowDotImport(pkgPath)
+		} else {
+			allowed = p.AllowImport(pkgPath)
+		}
+
+		if !allowed {
+			violations = append(violations, &PolicyViolation{
+				Pos:     fset.Position(spec.Pos()),
+				PkgPath: pkgPath,
+			})
+			continue
+		}
+
+		switch {
+		case spec.Name == nil:
+			imports[importPkgName(pkgPath)] = pkgPath
+		case spec.Name.Name != "_" && spec.Name.Name != ".":
+			imports[spec.Name.Name] = pkgPath
+		}
+	}
+
+	if len(imports) <= 0 {
+		return violations
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		selExpr, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		pkgIdent, ok := selExpr.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		// 已解析的标识符为文件内声明的变量，遮蔽了包名
+		if pkgIdent.Obj != nil {
+			return true
+		}
+
+		pkgPath, ok := imports[pkgIdent.Name]
+		if !ok {
+			return true
+		}
+
+		if !p.AllowSymbol(pkgPath, selExpr.Sel.Name) {
+			violations = append(violations, &PolicyViolation{
+				Pos:     fset.Position(selExpr.Pos()),
+				PkgPath: pkgPath,
+				Symbol:  selExpr.Sel.Name,
+			})
+		}
+
+		return true
+	})
+
+	return violations
+}
+
+// importPkgName 根据包路径推断默认包名，跳过主版本号后缀，例如"math/rand/v2"、"gopkg.in/yaml.v3"
+func importPkgName(pkgPath string) string {
+	name := path.Base(pkgPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(pkgPath))
+	}
+	if idx := strings.Index(name, ".v"); idx > 0 {
+		name = name[:idx]
+	}
+	return name
+}
@@ -0,0 +1,104 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dynamic
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strings"
+
+	"git.golaxy.org/framework/net/gap/variant"
+)
+
+// RPCExportDirective RPC导出指令，写在脚本方法的文档注释中，只有标记的方法才能作为RPC被调方法
+const RPCExportDirective = "//rpc:export"
+
+var (
+	variantPkgPath = reflect.TypeFor[variant.Variant]().PkgPath()
+	variantValueRT = reflect.TypeFor[variant.Value]()
+)
+
+// hasRPCExportDirective 文档注释中是否包含RPC导出指令
+func hasRPCExportDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, comment := range doc.List {
+		if strings.TrimSpace(comment.Text) == RPCExportDirective {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRPCMethod 检查RPC导出方法的参数类型，参数需要能够由variant转换得到
+func checkRPCMethod(script *Script, method *Method) error {
+	if !method.Reflected.IsValid() || method.Reflected.Kind() != reflect.Func {
+		return fmt.Errorf("rpc method %q is not a function", method.Name)
+	}
+
+	methodRT := method.Reflected.Type()
+
+	// 成员方法的第一个参数为接收者
+	first := 0
+	if script.Ident != "" {
+		first = 1
+	}
+
+	for i := first; i < methodRT.NumIn(); i++ {
+		paramRT := methodRT.In(i)
+		if methodRT.IsVariadic() && i == methodRT.NumIn()-1 {
+			paramRT = paramRT.Elem()
+		}
+		if !variantConvertible(paramRT) {
+			return fmt.Errorf("rpc method %q param %d type %s can't be converted from variant", method.Name, i-first, paramRT)
+		}
+	}
+
+	return nil
+}
+
+// variantConvertible 类型是否能够由variant转换得到
+func variantConvertible(rt reflect.Type) bool {
+	switch rt.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	case reflect.Slice:
+		if rt.Elem().Kind() == reflect.Uint8 {
+			return true
+		}
+	case reflect.Interface:
+		if rt.NumMethod() <= 0 {
+			return true
+		}
+	case reflect.Pointer:
+		rt = rt.Elem()
+	}
+
+	if rt.PkgPath() == variantPkgPath {
+		return true
+	}
+
+	return rt.Implements(variantValueRT) || reflect.PointerTo(rt).Implements(variantValueRT)
+}
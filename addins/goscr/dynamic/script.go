@@ -65,6 +65,7 @@ func (this *This) UniquePkgName() string {
 type Method struct {
 	Name      string        // 方法名
 	Reflected reflect.Value // 方法反射值
+	RPCExport bool          // 是否导出为RPC被调方法，使用//rpc:export标记
 }
 
 // MethodBinder 成员方法绑定器
@@ -86,6 +87,22 @@ func (s *Script) UniquePkgName() string {
 	return strings.NewReplacer("/", "_", ".", "_").Replace(s.PkgPath)
 }
 
+// Method 查询方法
+func (s *Script) Method(method string) *Method {
+	if s == nil {
+		return nil
+	}
+
+	idx, ok := slices.BinarySearchFunc(s.Methods, method, func(method *Method, target string) int {
+		return cmp.Compare(method.Name, target)
+	})
+	if !ok {
+		return nil
+	}
+
+	return s.Methods[idx]
+}
+
 // ScriptBundle 脚本集合
 type ScriptBundle map[string]*Script
 
@@ -181,8 +198,23 @@ func (lib ScriptLib) PushMethod(pkgPath, ident string, method string) bool {
 	return true
 }
 
+// ExportRPCMethod 标记方法导出为RPC被调方法
+func (lib ScriptLib) ExportRPCMethod(pkgPath, ident string, method string) bool {
+	m := lib.Package(pkgPath).Ident(ident).Method(method)
+	if m == nil {
+		return false
+	}
+	m.RPCExport = true
+	return true
+}
+
 // Load 加载
 func (lib ScriptLib) Load(codeFs *CodeFs, scriptPath string) error {
+	return lib.load(codeFs, scriptPath, nil, nil)
+}
+
+// load 加载，cache不为nil时记录解析结果、包源码哈希与导入关系，内容未变化的文件复用prevFiles中的语法树
+func (lib ScriptLib) load(codeFs *CodeFs, scriptPath string, cache *_ParseCache, prevFiles map[string]*_ParsedFile) error {
 	scriptPath = path.Clean(scriptPath)
 	fset := token.NewFileSet()
 
@@ -199,7 +231,7 @@ func (lib ScriptLib) Load(codeFs *CodeFs, scriptPath string) error {
 		}
 
 		pkgPath := path.Dir(filepath.ToSlash(filePath))
-		if !strings.HasPrefix(pkgPath, scriptPath) {
+		if !inScriptPath(pkgPath, scriptPath) {
 			return nil
 		}
 
@@ -208,9 +240,25 @@ func (lib ScriptLib) Load(codeFs *CodeFs, scriptPath string) error {
 			return fmt.Errorf("read script file %q failed, %s", filePath, err)
 		}
 
-		file, err := parser.ParseFile(fset, filePath, fileData, parser.AllErrors|parser.ParseComments)
-		if err != nil {
-			return fmt.Errorf("parse script file %q failed, %s", filePath, err)
+		var file *ast.File
+		var fileHash string
+
+		if cache != nil {
+			fileHash = hashFileData(fileData)
+			if prev, ok := prevFiles[filePath]; ok && prev.hash == fileHash {
+				file = prev.file
+			}
+		}
+
+		if file == nil {
+			file, err = parser.ParseFile(fset, filePath, fileData, parser.AllErrors|parser.ParseComments)
+			if err != nil {
+				return fmt.Errorf("parse script file %q failed, %s", filePath, err)
+			}
+		}
+
+		if cache != nil {
+			cache.add(pkgPath, filePath, &_ParsedFile{hash: fileHash, file: file})
 		}
 
 		codes = append(codes, &_Code{PkgPath: pkgPath, File: file})
@@ -223,6 +271,10 @@ func (lib ScriptLib) Load(codeFs *CodeFs, scriptPath string) error {
 		return err
 	}
 
+	if cache != nil {
+		cache.seal(scriptPath)
+	}
+
 	for _, code := range codes {
 		ast.Inspect(code.File, func(n ast.Node) bool {
 			genDecl, ok := n.(*ast.GenDecl)
@@ -323,18 +375,29 @@ func (lib ScriptLib) Load(codeFs *CodeFs, scriptPath string) error {
 				return true
 			}
 
+			rpcExport := hasRPCExportDirective(funcDecl.Doc)
+
 			if funcDecl.Recv == nil {
 				lib.PushMethod(code.PkgPath, "", funcDecl.Name.Name)
+				if rpcExport {
+					lib.ExportRPCMethod(code.PkgPath, "", funcDecl.Name.Name)
+				}
 				return true
 			}
 
 			for _, field := range funcDecl.Recv.List {
+				var ident *ast.Ident
 				if starExpr, ok := field.Type.(*ast.StarExpr); ok {
-					if ident, ok := starExpr.X.(*ast.Ident); ok {
-						lib.PushMethod(code.PkgPath, ident.Name, funcDecl.Name.Name)
-					}
-				} else if ident, ok := field.Type.(*ast.Ident); ok {
-					lib.PushMethod(code.PkgPath, ident.Name, funcDecl.Name.Name)
+					ident, _ = starExpr.X.(*ast.Ident)
+				} else {
+					ident, _ = field.Type.(*ast.Ident)
+				}
+				if ident == nil {
+					continue
+				}
+				lib.PushMethod(code.PkgPath, ident.Name, funcDecl.Name.Name)
+				if rpcExport {
+					lib.ExportRPCMethod(code.PkgPath, ident.Name, funcDecl.Name.Name)
 				}
 			}
 
@@ -347,11 +410,21 @@ func (lib ScriptLib) Load(codeFs *CodeFs, scriptPath string) error {
 
 // Compile 编译
 func (lib ScriptLib) Compile(i *interp.Interpreter, scriptPath string) error {
+	return lib.compile(i, scriptPath, nil)
+}
+
+// compile 编译，reuse中的包直接复用已编译的脚本，不再编译
+func (lib ScriptLib) compile(i *interp.Interpreter, scriptPath string, reuse ScriptLib) error {
 	scriptPath = path.Clean(scriptPath)
 	buff := &bytes.Buffer{}
 
 	for pkgPath, scriptBundle := range lib {
-		if !strings.HasPrefix(pkgPath, scriptPath) {
+		if !inScriptPath(pkgPath, scriptPath) {
+			continue
+		}
+
+		if reused, ok := reuse[pkgPath]; ok {
+			lib[pkgPath] = reused
 			continue
 		}
 
@@ -465,6 +538,12 @@ func Bind_{{.Ident}}(this any, method string) any {
 					}
 					method.Reflected = methodRV
 				}
+
+				if method.RPCExport {
+					if err := checkRPCMethod(script, method); err != nil {
+						return fmt.Errorf("script path %q ident %q check failed, %s", pkgPath, script.Ident, err)
+					}
+				}
 			}
 		}
 	}
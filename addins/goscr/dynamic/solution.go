@@ -20,74 +20,148 @@
 package dynamic
 
 import (
-	"archive/tar"
-	"archive/zip"
-	"bytes"
-	"cmp"
-	"compress/gzip"
-	"crypto/sha1"
+	"context"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
-	"io"
-	"io/fs"
-	"net/http"
-	"os"
 	"path"
-	"path/filepath"
 	"reflect"
 	"slices"
-	"strings"
+	"sync"
+	"time"
 
 	"git.golaxy.org/core/utils/generic"
-	"github.com/go-resty/resty/v2"
 	"github.com/pangdogs/yaegi/interp"
 	"github.com/spf13/afero"
 )
 
 // Project 项目
 type Project struct {
-	ScriptRoot string           // 脚本根路径
-	LocalPath  string           // 本地路径
-	RemoteURL  string           // 远程下载URL，支持打包格式：tar.gz、zip
-	SymbolsTab []interp.Exports // 符号表
+	ScriptRoot     string              // 脚本根路径
+	LocalPath      string              // 本地路径
+	RemoteURL      string              // 远程下载URL，支持打包格式：tar.gz、tgz、zip
+	ManifestURL    string              // 远程清单URL，清单格式见Manifest，脚本文件写入脚本根路径
+	RemoteDigest   string              // 远程下载文件的期望摘要，格式为<算法>:<十六进制摘要>，支持sha256、sha512
+	ManifestDigest string              // 远程清单的期望摘要，格式为<算法>:<十六进制摘要>，支持sha256、sha512
+	TrustedKeys    []ed25519.PublicKey // 受信任的ed25519公钥，不为空时需要远程下载文件或清单同路径下的.sig签名文件
+	Sources        []SourceProvider    // 自定义源码提供者，在LocalPath、RemoteURL、ManifestURL之后获取
+	SymbolsTab     []interp.Exports    // 符号表
+	sourcesOnce    sync.Once
+	sources        []SourceProvider
 }
 
 // NewSolution 创建解决方案
 func NewSolution(pkgRoot string) *Solution {
-	fs := NewCodeFs("src/main/vendor/")
+	return newSolution(pkgRoot, nil)
+}
 
-	i := interp.New(interp.Options{
-		SourcecodeFilesystem: fs,
-		Unrestricted:         true,
-	})
+// NewSandboxedSolution 创建沙箱化的解决方案，导入的符号表与脚本代码均受符号访问策略限制
+func NewSandboxedSolution(pkgRoot string, policy *SymbolPolicy) *Solution {
+	if policy == nil {
+		policy = &SymbolPolicy{}
+	}
+	return newSolution(pkgRoot, policy)
+}
+
+func newSolution(pkgRoot string, policy *SymbolPolicy) *Solution {
+	fs := NewCodeFs("src/main/vendor/")
 
 	return &Solution{
-		pkgRoot:   pkgRoot,
-		codeFs:    fs,
-		interp:    i,
-		scriptLib: NewScriptLib(),
+		pkgRoot:    pkgRoot,
+		codeFs:     fs,
+		policy:     policy,
+		interp:     newInterp(fs, policy),
+		scriptLib:  NewScriptLib(),
+		parseCache: newParseCache(),
 	}
 }
 
+func newInterp(fs *CodeFs, policy *SymbolPolicy) *interp.Interpreter {
+	return interp.New(interp.Options{
+		SourcecodeFilesystem: fs,
+		Unrestricted:         policy == nil,
+	})
+}
+
+// ErrSolutionReleased 解决方案已释放
+var ErrSolutionReleased = errors.New("solution released")
+
+// DefaultSourceTimeout 未指定上下文时，获取源码版本与源码的默认超时时间
+const DefaultSourceTimeout = time.Minute
+
 // Solution 解决方案
 type Solution struct {
-	pkgRoot    string
-	codeFs     *CodeFs
-	remoteHash generic.SliceMap[string, [sha1.Size]byte]
-	interp     *interp.Interpreter
-	scriptLib  ScriptLib
+	pkgRoot        string
+	codeFs         *CodeFs
+	sourceVersions []SourceVersion
+	policy         *SymbolPolicy
+	interp         *interp.Interpreter
+	symbolsTab     []interp.Exports
+	evalMu         sync.Mutex
+	scriptLib      ScriptLib
+	parseCache     *_ParseCache
+	incremental    *_Incremental
+	reused         []string
 }
 
-// Use 导入符号表
+// Use 导入符号表，沙箱化时只导入符号访问策略允许的符号
 func (s *Solution) Use(symbols interp.Exports) error {
-	return s.interp.Use(symbols)
+	symbols = s.policy.filter(symbols)
+	if err := s.interp.Use(symbols); err != nil {
+		return err
+	}
+	s.symbolsTab = append(s.symbolsTab, symbols)
+	return nil
+}
+
+// Policy 符号访问策略，未沙箱化时返回nil
+func (s *Solution) Policy() *SymbolPolicy {
+	return s.policy
 }
 
-// Eval 执行代码
+// Eval 执行代码，多个协程调用时串行执行
 func (s *Solution) Eval(code string) (reflect.Value, error) {
+	s.evalMu.Lock()
+	defer s.evalMu.Unlock()
+	if s.interp == nil {
+		return reflect.Value{}, ErrSolutionReleased
+	}
 	return s.interp.Eval(code)
 }
 
+// EvalIsolated 在一次性的解释器中执行代码，解释器导入与解决方案相同的符号表，并可以从解决方案的代码文件系统导入脚本包，
+// 脚本包在一次性的解释器中重新编译，执行代码不会读取或修改解决方案中脚本包的全局状态，定义的全局变量与函数也不会保留
+func (s *Solution) EvalIsolated(code string) (reflect.Value, error) {
+	s.evalMu.Lock()
+	if s.interp == nil {
+		s.evalMu.Unlock()
+		return reflect.Value{}, ErrSolutionReleased
+	}
+	fs := s.codeFs
+	symbolsTab := slices.Clone(s.symbolsTab)
+	s.evalMu.Unlock()
+
+	i := newInterp(fs, s.policy)
+	for _, symbols := range symbolsTab {
+		if err := i.Use(symbols); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return i.Eval(code)
+}
+
+// Release 释放解释器、已编译的脚本与解析缓存，释放后不能再执行或绑定脚本，只保留源码版本信息
+func (s *Solution) Release() {
+	s.evalMu.Lock()
+	defer s.evalMu.Unlock()
+	s.interp = nil
+	s.symbolsTab = nil
+	s.codeFs = nil
+	s.scriptLib = nil
+	s.parseCache = nil
+	s.incremental = nil
+}
+
 // Package 包
 func (s *Solution) Package(pkgPath string) ScriptBundle {
 	return s.scriptLib.Package(pkgPath)
@@ -98,8 +172,15 @@ func (s *Solution) Range(fun generic.Func2[string, ScriptBundle, bool]) {
 	s.scriptLib.Range(fun)
 }
 
-// Load 加载项目
+// Load 加载项目，获取源码版本与源码的超时时间为DefaultSourceTimeout
 func (s *Solution) Load(project *Project) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultSourceTimeout)
+	defer cancel()
+	return s.LoadWithContext(ctx, project)
+}
+
+// LoadWithContext 加载项目，使用ctx控制获取源码版本与源码的超时与取消
+func (s *Solution) LoadWithContext(ctx context.Context, project *Project) error {
 	scriptPath := path.Join(s.pkgRoot, project.ScriptRoot)
 
 	b, err := afero.Exists(s.codeFs.AferoFs(), scriptPath)
@@ -110,113 +191,85 @@ func (s *Solution) Load(project *Project) error {
 		return fmt.Errorf("script path %q conflicted", scriptPath)
 	}
 
-	if project.LocalPath != "" {
-		err = filepath.Walk(project.LocalPath, func(filePath string, fileInfo fs.FileInfo, err error) error {
-			if err != nil || fileInfo.IsDir() {
-				return nil
-			}
-
-			fileData, err := os.ReadFile(filePath)
-			if err != nil {
-				return fmt.Errorf("read local file %q failed, %s", filePath, err)
-			}
-
-			scriptFilePath, err := filepath.Rel(project.LocalPath, filePath)
-			if err != nil {
-				return fmt.Errorf("relative local file %q failed, %s", filePath, err)
-			}
-			scriptFilePath = path.Join(scriptPath, scriptFilePath)
-
-			err = afero.WriteFile(s.codeFs.AferoFs(), scriptFilePath, fileData, os.ModePerm)
-			if err != nil {
-				return fmt.Errorf("write script file %q failed, %s", scriptFilePath, err)
-			}
-
-			return nil
-		})
+	for _, provider := range project.SourceProviders() {
+		version, err := provider.Version(ctx)
 		if err != nil {
-			return err
+			return fmt.Errorf("script path %q get source version failed, %s", scriptPath, err)
 		}
-	}
 
-	if project.RemoteURL != "" {
-		resp, err := resty.New().
-			R().
-			Get(project.RemoteURL)
-		if err != nil {
-			return fmt.Errorf("download remote file %q failed, %s", project.RemoteURL, err)
-		}
-		if resp.StatusCode() != http.StatusOK {
-			return fmt.Errorf("download remote file %q failed, status code %d", project.RemoteURL, resp.StatusCode())
-		}
-
-		switch strings.ToLower(path.Ext(project.RemoteURL)) {
-		case ".tar.gz":
-			if err := s.extractTarGzip(resp.Body()); err != nil {
-				return fmt.Errorf("extract remote file %q failed, %s", project.RemoteURL, err)
-			}
-		case ".zip":
-			if err := s.extractZip(resp.Body()); err != nil {
-				return fmt.Errorf("extract remote file %q failed, %s", project.RemoteURL, err)
-			}
-		default:
-			return fmt.Errorf("unsupported remote file %q", project.RemoteURL)
+		if err := provider.Fetch(ctx, s.codeFs, scriptPath); err != nil {
+			return fmt.Errorf("script path %q fetch source failed, %s", scriptPath, err)
 		}
 
-		s.remoteHash.Add(project.RemoteURL, sha1.Sum(resp.Body()))
+		s.sourceVersions = append(s.sourceVersions, SourceVersion{ScriptPath: scriptPath, Provider: provider, Version: version})
 	}
 
 	for _, symbols := range project.SymbolsTab {
-		if err := s.interp.Use(symbols); err != nil {
+		if err := s.Use(symbols); err != nil {
 			return fmt.Errorf("script path %q use symbols failed, %s", scriptPath, err)
 		}
 	}
 
-	if err := s.scriptLib.Load(s.codeFs, scriptPath); err != nil {
+	if err := s.scriptLib.load(s.codeFs, scriptPath, s.parseCache, s.incremental.files()); err != nil {
 		return fmt.Errorf("load script path %q failed, %s", scriptPath, err)
 	}
 
-	if err := s.scriptLib.Compile(s.interp, scriptPath); err != nil {
+	if err := s.policy.check(s.codeFs, scriptPath); err != nil {
+		return fmt.Errorf("check script path %q symbol policy failed, %w", scriptPath, err)
+	}
+
+	reuse, err := s.incremental.reusable(s.parseCache, project, scriptPath, s.reused)
+	if err != nil {
+		return fmt.Errorf("script path %q incremental compile failed, %w", scriptPath, err)
+	}
+
+	if err := s.scriptLib.compile(s.interp, scriptPath, reuse); err != nil {
 		return fmt.Errorf("compile script path %q failed, %s", scriptPath, err)
 	}
 
+	for pkgPath := range reuse {
+		s.reused = append(s.reused, pkgPath)
+	}
+	slices.Sort(s.reused)
+
 	return nil
 }
 
-// DetectRemoteChanged 检测项目远程下载文件是否有变化
-func (s *Solution) DetectRemoteChanged() (bool, error) {
-	for _, kv := range s.remoteHash {
-		resp, err := resty.New().
-			R().
-			Get(kv.K)
+// SourceVersions 加载时的源码版本
+func (s *Solution) SourceVersions() []SourceVersion {
+	return slices.Clone(s.sourceVersions)
+}
+
+// DetectChanged 检测项目源码是否有变化
+func (s *Solution) DetectChanged(ctx context.Context) (bool, error) {
+	for _, sv := range s.sourceVersions {
+		version, err := sv.Provider.Version(ctx)
 		if err != nil {
-			return false, fmt.Errorf("download remote file %q failed, %s", kv.K, err)
-		}
-		if resp.StatusCode() != http.StatusOK {
-			return false, fmt.Errorf("download remote file %q failed, status code %d", kv.K, resp.StatusCode())
+			return false, err
 		}
-		if sha1.Sum(resp.Body()) != kv.V {
+		if version != sv.Version {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
+// DetectRemoteChanged 检测项目源码是否有变化
+//
+// Deprecated: 使用DetectChanged，超时时间为DefaultSourceTimeout
+func (s *Solution) DetectRemoteChanged() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultSourceTimeout)
+	defer cancel()
+	return s.DetectChanged(ctx)
+}
+
 // Method 方法
 func (s *Solution) Method(pkgPath, method string) reflect.Value {
-	script := s.scriptLib.Package(pkgPath).Ident("")
-	if script == nil {
-		return reflect.Value{}
-	}
-
-	idx, ok := slices.BinarySearchFunc(script.Methods, method, func(method *Method, target string) int {
-		return cmp.Compare(method.Name, target)
-	})
-	if !ok {
+	m := s.scriptLib.Package(pkgPath).Ident("").Method(method)
+	if m == nil {
 		return reflect.Value{}
 	}
-
-	return script.Methods[idx].Reflected
+	return m.Reflected
 }
 
 // BindMethod 绑定成员方法
@@ -254,103 +307,3 @@ func (s *Solution) BindMethod(this reflect.Value, pkgPath, ident string, method
 
 	return ret
 }
-
-func (s *Solution) extractTarGzip(data []byte) error {
-	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
-	if err != nil {
-		return err
-	}
-	defer gzipReader.Close()
-
-	tarReader := tar.NewReader(gzipReader)
-
-	for {
-		header, err := tarReader.Next()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
-			return err
-		}
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := s.codeFs.AferoFs().MkdirAll(header.Name, os.ModePerm); err != nil {
-				return err
-			}
-		case tar.TypeReg:
-			if err := s.codeFs.AferoFs().MkdirAll(filepath.Dir(header.Name), os.ModePerm); err != nil {
-				return err
-			}
-
-			err := func() error {
-				dstFile, err := s.codeFs.AferoFs().Create(header.Name)
-				if err != nil {
-					return err
-				}
-				defer dstFile.Close()
-
-				if _, err := io.Copy(dstFile, tarReader); err != nil {
-					return err
-				}
-
-				return nil
-			}()
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
-
-func (s *Solution) extractZip(data []byte) error {
-	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
-	if err != nil {
-		return err
-	}
-
-	for _, zipFile := range zipReader.File {
-		filePath := filepath.Clean(zipFile.Name)
-		if strings.Contains(filePath, "..") {
-			continue
-		}
-
-		if zipFile.FileInfo().IsDir() {
-			if err := s.codeFs.AferoFs().MkdirAll(filePath, os.ModePerm); err != nil {
-				return err
-			}
-			continue
-		}
-
-		if err := s.codeFs.AferoFs().MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-			return err
-		}
-
-		err := func() error {
-			zipFileReader, err := zipFile.Open()
-			if err != nil {
-				return err
-			}
-			defer zipFileReader.Close()
-
-			dstFile, err := s.codeFs.AferoFs().OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
-			if err != nil {
-				return err
-			}
-			defer dstFile.Close()
-
-			if _, err := io.Copy(dstFile, zipFileReader); err != nil {
-				return err
-			}
-
-			return nil
-		}()
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
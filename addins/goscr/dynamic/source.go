@@ -0,0 +1,73 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dynamic
+
+import (
+	"context"
+)
+
+// SourceEvent 源码变化事件
+type SourceEvent struct {
+	Path string // 变化的文件路径，相对于脚本根路径，为空表示无法确定
+	Op   string // 变化操作
+	Err  error  // 监听错误
+}
+
+// SourceProvider 脚本源码提供者
+type SourceProvider interface {
+	// Fetch 获取源码，写入代码文件系统的脚本根路径
+	Fetch(ctx context.Context, codeFs *CodeFs, scriptPath string) error
+	// Version 源码版本，版本变化表示需要重新加载
+	Version(ctx context.Context) (string, error)
+	// Watch 监听源码变化，不支持监听时返回nil，需要轮询Version
+	Watch(ctx context.Context) (<-chan SourceEvent, error)
+}
+
+// SourceProviders 项目的所有源码提供者，LocalPath、RemoteURL、ManifestURL分别对应内置的提供者，按顺序排在Sources之前，后获取的文件覆盖先获取的文件
+func (project *Project) SourceProviders() []SourceProvider {
+	project.sourcesOnce.Do(func() {
+		if project.LocalPath != "" {
+			project.sources = append(project.sources, &LocalSource{Path: project.LocalPath})
+		}
+		if project.RemoteURL != "" {
+			project.sources = append(project.sources, &RemoteArchiveSource{
+				URL:         project.RemoteURL,
+				Digest:      project.RemoteDigest,
+				TrustedKeys: project.TrustedKeys,
+			})
+		}
+		if project.ManifestURL != "" {
+			project.sources = append(project.sources, &ManifestSource{
+				URL:         project.ManifestURL,
+				Digest:      project.ManifestDigest,
+				TrustedKeys: project.TrustedKeys,
+			})
+		}
+		project.sources = append(project.sources, project.Sources...)
+	})
+	return project.sources
+}
+
+// SourceVersion 加载时的源码版本
+type SourceVersion struct {
+	ScriptPath string         // 脚本路径
+	Provider   SourceProvider // 源码提供者
+	Version    string         // 源码版本
+}
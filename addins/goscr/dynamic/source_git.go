@@ -0,0 +1,93 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dynamic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// GitSource 本地git仓库源码，使用git命令读取指定引用（分支、标签或提交）下的文件，不影响仓库工作区
+type GitSource struct {
+	Repo string // 仓库路径
+	Ref  string // 引用，为空时使用HEAD
+	Dir  string // 仓库内的脚本目录，为空时使用仓库根目录
+}
+
+// Fetch 获取源码，写入代码文件系统的脚本根路径
+func (src *GitSource) Fetch(ctx context.Context, codeFs *CodeFs, scriptPath string) error {
+	commit, err := src.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"archive", "--format=tar", commit}
+	if src.Dir != "" {
+		args = append(args, "--", src.Dir)
+	}
+
+	data, err := src.git(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	if err := extractTar(codeFs.AferoFs(), scriptPath, src.Dir, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("extract git repo %q commit %q failed, %s", src.Repo, commit, err)
+	}
+
+	return nil
+}
+
+// Version 源码版本，为引用指向的提交
+func (src *GitSource) Version(ctx context.Context) (string, error) {
+	ref := src.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	data, err := src.git(ctx, "rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Watch 不支持监听，需要轮询Version
+func (src *GitSource) Watch(ctx context.Context) (<-chan SourceEvent, error) {
+	return nil, nil
+}
+
+func (src *GitSource) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", src.Repo}, args...)...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git repo %q %s failed, %s, %s", src.Repo, args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return data, nil
+}
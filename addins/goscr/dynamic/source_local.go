@@ -0,0 +1,165 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dynamic
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+)
+
+// LocalSource 本地目录源码
+type LocalSource struct {
+	Path string // 本地路径
+}
+
+// Fetch 获取源码，写入代码文件系统的脚本根路径
+func (src *LocalSource) Fetch(ctx context.Context, codeFs *CodeFs, scriptPath string) error {
+	return filepath.Walk(src.Path, func(filePath string, fileInfo fs.FileInfo, err error) error {
+		if err != nil || fileInfo.IsDir() {
+			return nil
+		}
+
+		fileData, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("read local file %q failed, %s", filePath, err)
+		}
+
+		scriptFilePath, err := filepath.Rel(src.Path, filePath)
+		if err != nil {
+			return fmt.Errorf("relative local file %q failed, %s", filePath, err)
+		}
+		scriptFilePath = path.Join(scriptPath, filepath.ToSlash(scriptFilePath))
+
+		err = afero.WriteFile(codeFs.AferoFs(), scriptFilePath, fileData, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("write script file %q failed, %s", scriptFilePath, err)
+		}
+
+		return nil
+	})
+}
+
+// Version 源码版本，使用所有文件的路径与内容计算摘要
+func (src *LocalSource) Version(ctx context.Context) (string, error) {
+	h := sha1.New()
+
+	err := filepath.Walk(src.Path, func(filePath string, fileInfo fs.FileInfo, err error) error {
+		if err != nil || fileInfo.IsDir() {
+			return nil
+		}
+
+		fileData, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("read local file %q failed, %s", filePath, err)
+		}
+
+		relPath, err := filepath.Rel(src.Path, filePath)
+		if err != nil {
+			return fmt.Errorf("relative local file %q failed, %s", filePath, err)
+		}
+
+		h.Write([]byte(filepath.ToSlash(relPath)))
+		h.Write([]byte{0})
+		h.Write(fileData)
+		h.Write([]byte{0})
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Watch 监听本地目录及其子目录的变化
+func (src *LocalSource) Watch(ctx context.Context) (<-chan SourceEvent, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("watch local path %q failed, %s", src.Path, err)
+	}
+
+	err = filepath.Walk(src.Path, func(filePath string, fileInfo fs.FileInfo, err error) error {
+		if err != nil || !fileInfo.IsDir() {
+			return nil
+		}
+		return watcher.Add(filePath)
+	})
+	if err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("watch local path %q failed, %s", src.Path, err)
+	}
+
+	events := make(chan SourceEvent, 64)
+
+	go func() {
+		defer close(events)
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if e.Has(fsnotify.Create) {
+					if fileInfo, err := os.Stat(e.Name); err == nil && fileInfo.IsDir() {
+						watcher.Add(e.Name)
+					}
+				}
+
+				event := SourceEvent{Op: e.Op.String()}
+				if relPath, err := filepath.Rel(src.Path, e.Name); err == nil {
+					event.Path = filepath.ToSlash(relPath)
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				select {
+				case events <- SourceEvent{Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,167 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dynamic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OCISource 本地OCI镜像布局（OCI Image Layout）源码，按顺序解压镜像清单中的所有层，层格式支持tar、tar+gzip
+type OCISource struct {
+	LayoutPath string // 镜像布局路径，包含oci-layout、index.json与blobs目录
+	Ref        string // 镜像引用名，匹配org.opencontainers.image.ref.name注解，为空时要求index.json只有一个镜像清单
+}
+
+type _OCIDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type _OCIIndex struct {
+	Manifests []_OCIDescriptor `json:"manifests"`
+}
+
+type _OCIManifest struct {
+	Layers []_OCIDescriptor `json:"layers"`
+}
+
+// Fetch 获取源码，写入代码文件系统的脚本根路径
+func (src *OCISource) Fetch(ctx context.Context, codeFs *CodeFs, scriptPath string) error {
+	manifestDesc, err := src.manifest()
+	if err != nil {
+		return err
+	}
+
+	manifestData, err := src.blob(manifestDesc.Digest)
+	if err != nil {
+		return err
+	}
+
+	var manifest _OCIManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return fmt.Errorf("parse oci layout %q manifest %q failed, %s", src.LayoutPath, manifestDesc.Digest, err)
+	}
+
+	for _, layer := range manifest.Layers {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		layerData, err := src.blob(layer.Digest)
+		if err != nil {
+			return err
+		}
+
+		if err := src.extractLayer(codeFs, scriptPath, layer, layerData); err != nil {
+			return fmt.Errorf("extract oci layout %q layer %q failed, %s", src.LayoutPath, layer.Digest, err)
+		}
+	}
+
+	return nil
+}
+
+func (src *OCISource) extractLayer(codeFs *CodeFs, scriptPath string, layer _OCIDescriptor, layerData []byte) error {
+	var r io.Reader = bytes.NewReader(layerData)
+
+	if strings.HasSuffix(layer.MediaType, "gzip") || bytes.HasPrefix(layerData, []byte{0x1f, 0x8b}) {
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		r = gzipReader
+	}
+
+	return extractTar(codeFs.AferoFs(), scriptPath, "", r)
+}
+
+// Version 源码版本，为镜像清单的摘要
+func (src *OCISource) Version(ctx context.Context) (string, error) {
+	manifestDesc, err := src.manifest()
+	if err != nil {
+		return "", err
+	}
+	return manifestDesc.Digest, nil
+}
+
+// Watch 不支持监听，需要轮询Version
+func (src *OCISource) Watch(ctx context.Context) (<-chan SourceEvent, error) {
+	return nil, nil
+}
+
+func (src *OCISource) manifest() (*_OCIDescriptor, error) {
+	indexData, err := os.ReadFile(filepath.Join(src.LayoutPath, "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("read oci layout %q index failed, %s", src.LayoutPath, err)
+	}
+
+	var index _OCIIndex
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		return nil, fmt.Errorf("parse oci layout %q index failed, %s", src.LayoutPath, err)
+	}
+
+	if src.Ref == "" {
+		if len(index.Manifests) != 1 {
+			return nil, fmt.Errorf("oci layout %q has %d manifests, ref required", src.LayoutPath, len(index.Manifests))
+		}
+		return &index.Manifests[0], nil
+	}
+
+	for i := range index.Manifests {
+		if index.Manifests[i].Annotations["org.opencontainers.image.ref.name"] == src.Ref {
+			return &index.Manifests[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("oci layout %q ref %q not found", src.LayoutPath, src.Ref)
+}
+
+// blob 读取并校验blob
+func (src *OCISource) blob(digest string) ([]byte, error) {
+	algo, sum, ok := strings.Cut(digest, ":")
+	if !ok || algo == "" || sum == "" || strings.ContainsAny(digest, `/\.`) {
+		return nil, fmt.Errorf("oci layout %q has incorrect digest %q", src.LayoutPath, digest)
+	}
+
+	data, err := os.ReadFile(filepath.Join(src.LayoutPath, "blobs", algo, sum))
+	if err != nil {
+		return nil, fmt.Errorf("read oci layout %q blob %q failed, %s", src.LayoutPath, digest, err)
+	}
+
+	if err := verifyDigest(digest, data); err != nil {
+		if errors.Is(err, ErrRemoteVerification) {
+			return nil, fmt.Errorf("oci layout %q blob %q corrupted, %s", src.LayoutPath, digest, err)
+		}
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,103 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dynamic
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// OverlaySource 分层源码，按顺序获取各层源码，上层的文件逐个覆盖下层的同名文件，例如使用热更新目录覆盖基础目录
+type OverlaySource struct {
+	Layers []SourceProvider // 源码层，靠后的层优先
+}
+
+// Fetch 获取源码，写入代码文件系统的脚本根路径
+func (src *OverlaySource) Fetch(ctx context.Context, codeFs *CodeFs, scriptPath string) error {
+	for _, layer := range src.Layers {
+		if err := layer.Fetch(ctx, codeFs, scriptPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Version 源码版本，由各层版本组成
+func (src *OverlaySource) Version(ctx context.Context) (string, error) {
+	versions := make([]string, 0, len(src.Layers))
+	for _, layer := range src.Layers {
+		version, err := layer.Version(ctx)
+		if err != nil {
+			return "", err
+		}
+		versions = append(versions, version)
+	}
+	return strings.Join(versions, ","), nil
+}
+
+// Watch 合并监听各层的变化，只要有一层不支持监听，就返回nil，需要轮询Version
+func (src *OverlaySource) Watch(ctx context.Context) (<-chan SourceEvent, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	layerEvents := make([]<-chan SourceEvent, 0, len(src.Layers))
+	for _, layer := range src.Layers {
+		events, err := layer.Watch(ctx)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		if events == nil {
+			cancel()
+			return nil, nil
+		}
+		layerEvents = append(layerEvents, events)
+	}
+
+	if len(layerEvents) <= 0 {
+		cancel()
+		return nil, nil
+	}
+
+	merged := make(chan SourceEvent, 64)
+	var wg sync.WaitGroup
+
+	for _, events := range layerEvents {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for event := range events {
+				select {
+				case merged <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(merged)
+	}()
+
+	return merged, nil
+}
@@ -0,0 +1,302 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dynamic
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/spf13/afero"
+)
+
+// Manifest 远程清单，列出脚本文件与摘要，只下载有变化的文件
+type Manifest struct {
+	BaseURL string            `json:"base_url"` // 脚本文件下载根URL，为空时使用清单所在目录
+	Files   map[string]string `json:"files"`    // 脚本文件相对路径与sha256摘要（十六进制）
+}
+
+// _RemoteState 远程文件状态，用于发送条件请求
+type _RemoteState struct {
+	Hash         [sha1.Size]byte
+	ETag         string
+	LastModified string
+}
+
+// _RemoteFile 远程文件，使用ETag与Last-Modified发送条件请求，未变化时复用上次下载的数据
+type _RemoteFile struct {
+	URL       string
+	state     *_RemoteState
+	data      []byte
+	versioned bool
+}
+
+// download 下载，未变化时不重新下载
+func (f *_RemoteFile) download(ctx context.Context) error {
+	req := resty.New().R().SetContext(ctx)
+	if f.state != nil {
+		if f.state.ETag != "" {
+			req.SetHeader("If-None-Match", f.state.ETag)
+		}
+		if f.state.LastModified != "" {
+			req.SetHeader("If-Modified-Since", f.state.LastModified)
+		}
+	}
+
+	resp, err := req.Get(f.URL)
+	if err != nil {
+		return fmt.Errorf("download remote file %q failed, %s", f.URL, err)
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusNotModified:
+		if f.state != nil {
+			return nil
+		}
+	case http.StatusOK:
+		f.data = resp.Body()
+		f.state = &_RemoteState{
+			Hash:         sha1.Sum(resp.Body()),
+			ETag:         resp.Header().Get("ETag"),
+			LastModified: resp.Header().Get("Last-Modified"),
+		}
+		return nil
+	}
+
+	return fmt.Errorf("download remote file %q failed, status code %d", f.URL, resp.StatusCode())
+}
+
+// version 下载并获取版本，下载的数据留给下一次fetch使用
+func (f *_RemoteFile) version(ctx context.Context) (string, error) {
+	f.versioned = false
+	if err := f.download(ctx); err != nil {
+		return "", err
+	}
+	f.versioned = true
+	return hex.EncodeToString(f.state.Hash[:]), nil
+}
+
+// fetch 获取数据，优先使用上一次version下载的数据，保证加载的源码与记录的版本一致，服务器忽略条件请求时也不会重复下载
+func (f *_RemoteFile) fetch(ctx context.Context) error {
+	if f.versioned && f.state != nil {
+		f.versioned = false
+		return nil
+	}
+	return f.download(ctx)
+}
+
+// RemoteArchiveSource 远程打包文件源码，支持打包格式：tar.gz、tgz、zip，按打包文件内的路径解压
+type RemoteArchiveSource struct {
+	URL         string              // 远程下载URL
+	Digest      string              // 期望摘要，格式为<算法>:<十六进制摘要>，支持sha256、sha512
+	TrustedKeys []ed25519.PublicKey // 受信任的ed25519公钥，不为空时需要同路径下的.sig签名文件
+	mu          sync.Mutex
+	file        *_RemoteFile
+}
+
+// Fetch 获取源码，写入代码文件系统的脚本根路径，优先使用Version下载的打包文件
+func (src *RemoteArchiveSource) Fetch(ctx context.Context, codeFs *CodeFs, scriptPath string) error {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	file := src.remoteFile()
+	if err := file.fetch(ctx); err != nil {
+		return err
+	}
+
+	if err := verifyRemote(ctx, src.Digest, src.TrustedKeys, src.URL, file.data); err != nil {
+		return fmt.Errorf("verify remote file %q failed, %w", src.URL, err)
+	}
+
+	switch remoteArchiveExt(src.URL) {
+	case ".tar.gz", ".tgz":
+		if err := extractTarGzip(codeFs.AferoFs(), "", file.data); err != nil {
+			return fmt.Errorf("extract remote file %q failed, %s", src.URL, err)
+		}
+	case ".zip":
+		if err := extractZip(codeFs.AferoFs(), "", file.data); err != nil {
+			return fmt.Errorf("extract remote file %q failed, %s", src.URL, err)
+		}
+	default:
+		return fmt.Errorf("unsupported remote file %q", src.URL)
+	}
+
+	return nil
+}
+
+// Version 源码版本，版本变化表示需要重新加载
+func (src *RemoteArchiveSource) Version(ctx context.Context) (string, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	return src.remoteFile().version(ctx)
+}
+
+// Watch 不支持监听，需要轮询Version
+func (src *RemoteArchiveSource) Watch(ctx context.Context) (<-chan SourceEvent, error) {
+	return nil, nil
+}
+
+func (src *RemoteArchiveSource) remoteFile() *_RemoteFile {
+	if src.file == nil || src.file.URL != src.URL {
+		src.file = &_RemoteFile{URL: src.URL}
+	}
+	return src.file
+}
+
+// ManifestSource 远程清单源码，清单格式见Manifest，脚本文件写入脚本根路径，摘要未变化的文件不再重新下载
+type ManifestSource struct {
+	URL         string              // 远程清单URL
+	Digest      string              // 清单的期望摘要，格式为<算法>:<十六进制摘要>，支持sha256、sha512
+	TrustedKeys []ed25519.PublicKey // 受信任的ed25519公钥，不为空时需要清单同路径下的.sig签名文件
+	mu          sync.Mutex
+	file        *_RemoteFile
+	files       map[string][]byte
+}
+
+// Fetch 获取源码，写入代码文件系统的脚本根路径，优先使用Version下载的清单
+func (src *ManifestSource) Fetch(ctx context.Context, codeFs *CodeFs, scriptPath string) error {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	file := src.remoteFile()
+	if err := file.fetch(ctx); err != nil {
+		return err
+	}
+
+	if err := verifyRemote(ctx, src.Digest, src.TrustedKeys, src.URL, file.data); err != nil {
+		return fmt.Errorf("verify remote manifest %q failed, %w", src.URL, err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(file.data, &manifest); err != nil {
+		return fmt.Errorf("parse remote manifest %q failed, %s", src.URL, err)
+	}
+
+	baseURL, err := url.Parse(src.URL)
+	if err != nil {
+		return fmt.Errorf("parse remote manifest url %q failed, %s", src.URL, err)
+	}
+	if manifest.BaseURL != "" {
+		ref, err := url.Parse(manifest.BaseURL)
+		if err != nil {
+			return fmt.Errorf("parse remote manifest %q base url %q failed, %s", src.URL, manifest.BaseURL, err)
+		}
+		baseURL = baseURL.ResolveReference(ref)
+	}
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path = path.Dir(baseURL.Path) + "/"
+		baseURL.RawPath = ""
+	}
+
+	filePaths := make([]string, 0, len(manifest.Files))
+	for filePath := range manifest.Files {
+		filePaths = append(filePaths, filePath)
+	}
+	slices.Sort(filePaths)
+
+	files := make(map[string][]byte, len(filePaths))
+
+	for _, filePath := range filePaths {
+		sum := strings.ToLower(manifest.Files[filePath])
+
+		cleanPath := path.Clean(filePath)
+		if path.IsAbs(cleanPath) || cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
+			return fmt.Errorf("remote manifest %q has incorrect file path %q", src.URL, filePath)
+		}
+
+		fileData, ok := src.files[sum]
+		if !ok {
+			fileURL := baseURL.ResolveReference(&url.URL{Path: cleanPath}).String()
+
+			remoteFile := &_RemoteFile{URL: fileURL}
+			if err := remoteFile.download(ctx); err != nil {
+				return err
+			}
+			fileData = remoteFile.data
+
+			fileSum := sha256.Sum256(fileData)
+			if hex.EncodeToString(fileSum[:]) != sum {
+				return fmt.Errorf("verify remote file %q failed, %w: digest mismatch", fileURL, ErrRemoteVerification)
+			}
+		}
+		files[sum] = fileData
+
+		scriptFilePath := path.Join(scriptPath, cleanPath)
+
+		err = afero.WriteFile(codeFs.AferoFs(), scriptFilePath, fileData, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("write script file %q failed, %s", scriptFilePath, err)
+		}
+	}
+
+	src.files = files
+
+	return nil
+}
+
+// Version 源码版本，版本变化表示需要重新加载
+func (src *ManifestSource) Version(ctx context.Context) (string, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+
+	return src.remoteFile().version(ctx)
+}
+
+// Watch 不支持监听，需要轮询Version
+func (src *ManifestSource) Watch(ctx context.Context) (<-chan SourceEvent, error) {
+	return nil, nil
+}
+
+func (src *ManifestSource) remoteFile() *_RemoteFile {
+	if src.file == nil || src.file.URL != src.URL {
+		src.file = &_RemoteFile{URL: src.URL}
+	}
+	return src.file
+}
+
+// remoteArchiveExt 远程打包文件扩展名，path.Ext无法识别.tar.gz
+func remoteArchiveExt(remoteURL string) string {
+	p := remoteURL
+	if u, err := url.Parse(remoteURL); err == nil {
+		p = u.Path
+	}
+	p = strings.ToLower(p)
+
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(p, ext) {
+			return ext
+		}
+	}
+
+	return path.Ext(p)
+}
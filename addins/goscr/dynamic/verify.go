@@ -0,0 +1,155 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package dynamic
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrRemoteVerification 远程文件校验失败
+var ErrRemoteVerification = errors.New("remote file verification failed")
+
+// verifyRemote 校验远程下载文件，需要在解压前调用
+func verifyRemote(ctx context.Context, digest string, trustedKeys []ed25519.PublicKey, remoteURL string, data []byte) error {
+	if digest != "" {
+		if err := verifyDigest(digest, data); err != nil {
+			return err
+		}
+	}
+
+	if len(trustedKeys) > 0 {
+		sigURL, err := signatureURL(remoteURL)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrRemoteVerification, err)
+		}
+
+		resp, err := resty.New().
+			R().
+			SetContext(ctx).
+			Get(sigURL)
+		if err != nil {
+			return fmt.Errorf("%w: download signature file %q failed, %s", ErrRemoteVerification, sigURL, err)
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("%w: download signature file %q failed, status code %d", ErrRemoteVerification, sigURL, resp.StatusCode())
+		}
+
+		if err := verifySignature(trustedKeys, data, resp.Body()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyDigest 校验摘要，格式为<算法>:<十六进制摘要>，支持sha256、sha512，省略算法时使用sha256
+func verifyDigest(digest string, data []byte) error {
+	algo, sum, ok := strings.Cut(digest, ":")
+	if !ok {
+		algo, sum = "sha256", digest
+	}
+
+	var h hash.Hash
+
+	switch strings.ToLower(algo) {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("%w: unsupported digest algorithm %q", ErrRemoteVerification, algo)
+	}
+
+	expected, err := hex.DecodeString(strings.TrimSpace(sum))
+	if err != nil {
+		return fmt.Errorf("%w: incorrect digest %q, %s", ErrRemoteVerification, digest, err)
+	}
+
+	h.Write(data)
+
+	if subtle.ConstantTimeCompare(h.Sum(nil), expected) != 1 {
+		return fmt.Errorf("%w: digest mismatch", ErrRemoteVerification)
+	}
+
+	return nil
+}
+
+// verifySignature 校验ed25519签名，签名文件可以是原始字节，也可以是base64或十六进制文本
+func verifySignature(keys []ed25519.PublicKey, data, sigData []byte) error {
+	sig, err := decodeSignature(sigData)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRemoteVerification, err)
+	}
+
+	for _, key := range keys {
+		if len(key) != ed25519.PublicKeySize {
+			continue
+		}
+		if ed25519.Verify(key, data, sig) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: signature not signed by any trusted key", ErrRemoteVerification)
+}
+
+func decodeSignature(sigData []byte) ([]byte, error) {
+	if len(sigData) == ed25519.SignatureSize {
+		return sigData, nil
+	}
+
+	text := string(bytes.TrimSpace(sigData))
+
+	if sig, err := base64.StdEncoding.DecodeString(text); err == nil && len(sig) == ed25519.SignatureSize {
+		return sig, nil
+	}
+
+	if sig, err := hex.DecodeString(text); err == nil && len(sig) == ed25519.SignatureSize {
+		return sig, nil
+	}
+
+	return nil, errors.New("incorrect signature format")
+}
+
+// signatureURL 签名文件URL，与远程文件同路径，增加.sig后缀
+func signatureURL(remoteURL string) (string, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path += ".sig"
+	u.RawPath = ""
+	return u.String(), nil
+}
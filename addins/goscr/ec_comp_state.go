@@ -30,11 +30,12 @@ import (
 // ComponentState 脚本化组件状态
 type ComponentState struct {
 	framework.ComponentBehavior
+	methods _ScriptMethods
 }
 
 // Callee 被调函数
 func (c *ComponentState) Callee(method string) reflect.Value {
-	return reflect.ValueOf(c.bindMethod(method))
+	return c.scriptMethods().callee(method)
 }
 
 // Awake 生命周期唤醒（Awake）
@@ -47,26 +48,17 @@ func (c *ComponentState) Awake() {
 		return
 	}
 
-	method, _ := c.bindMethod("Awake").(func())
-	if method != nil {
-		method()
-	}
+	c.callMethod("Awake")
 }
 
 // OnEnable 生命周期启用（OnEnable）
 func (c *ComponentState) OnEnable() {
-	method, _ := c.bindMethod("OnEnable").(func())
-	if method != nil {
-		method()
-	}
+	c.callMethod("OnEnable")
 }
 
 // Start 生命周期开始（Start）
 func (c *ComponentState) Start() {
-	method, _ := c.bindMethod("Start").(func())
-	if method != nil {
-		method()
-	}
+	c.callMethod("Start")
 
 	if c.State() != ec.ComponentState_Starting {
 		return
@@ -79,6 +71,8 @@ func (c *ComponentState) Start() {
 
 // Shut 生命周期结束（Shut）
 func (c *ComponentState) Shut() {
+	defer c.methods.unsubscribe()
+
 	if cb, ok := c.Reflected().Interface().(LifecycleComponentOnStop); ok {
 		generic.CastAction0(cb.OnStop).Call(c.Runtime().AutoRecover(), c.Runtime().ReportError())
 	}
@@ -87,26 +81,18 @@ func (c *ComponentState) Shut() {
 		return
 	}
 
-	method, _ := c.bindMethod("Shut").(func())
-	if method != nil {
-		method()
-	}
+	c.callMethod("Shut")
 }
 
 // OnDisable 生命周期关闭（OnDisable）
 func (c *ComponentState) OnDisable() {
-	method, _ := c.bindMethod("OnDisable").(func())
-	if method != nil {
-		method()
-	}
+	c.callMethod("OnDisable")
 }
 
 // Dispose 生命周期死亡（Death）
 func (c *ComponentState) Dispose() {
-	method, _ := c.bindMethod("Dispose").(func())
-	if method != nil {
-		method()
-	}
+	c.callMethod("Dispose")
+	c.methods.release()
 
 	if c.State() != ec.ComponentState_Dead {
 		return
@@ -117,23 +103,16 @@ func (c *ComponentState) Dispose() {
 	}
 }
 
-func (c *ComponentState) bindMethod(method string) any {
-	scriptPkg, ok := c.Builtin().Meta.Get("script_pkg")
-	if !ok {
-		return nil
-	}
-
-	scriptIdent, ok := c.Builtin().Meta.Get("script_ident")
-	if !ok {
-		return nil
-	}
+func (c *ComponentState) callMethod(method string) {
+	c.scriptMethods().call(method)
+}
 
-	thisMethod := AddIn.Require(c.Service()).Solution().BindMethod(c.Reflected(), scriptPkg.(string), scriptIdent.(string), method)
-	if thisMethod == nil {
-		return nil
-	}
+func (c *ComponentState) liveScriptMethods() *_ScriptMethods {
+	return c.methods.live()
+}
 
-	return thisMethod
+func (c *ComponentState) scriptMethods() *_ScriptMethods {
+	return c.methods.init(c.Service(), c.Runtime(), c.Entity(), c.Builtin().Meta, c.Reflected(), func() { c.SetEnabled(false) })
 }
 
 // ComponentStateEnableUpdate 脚本化组件状态，支持帧更新（Update）
@@ -143,10 +122,7 @@ type ComponentStateEnableUpdate struct {
 
 // Update 帧更新（Update）
 func (c *ComponentStateEnableUpdate) Update() {
-	method, _ := c.bindMethod("Update").(func())
-	if method != nil {
-		method()
-	}
+	c.callMethod("Update")
 }
 
 // ComponentStateEnableLateUpdate 脚本化组件状态，支持帧迟滞更新（Late Update）
@@ -156,10 +132,7 @@ type ComponentStateEnableLateUpdate struct {
 
 // LateUpdate 帧迟滞更新（Late Update）
 func (c *ComponentStateEnableLateUpdate) LateUpdate() {
-	method, _ := c.bindMethod("LateUpdate").(func())
-	if method != nil {
-		method()
-	}
+	c.callMethod("LateUpdate")
 }
 
 // ComponentStateEnableUpdateAndLateUpdate 脚本化组件状态，支持帧更新（Update）、帧迟滞更新（Late Update）
@@ -169,16 +142,10 @@ type ComponentStateEnableUpdateAndLateUpdate struct {
 
 // Update 帧更新（Update）
 func (c *ComponentStateEnableUpdateAndLateUpdate) Update() {
-	method, _ := c.bindMethod("Update").(func())
-	if method != nil {
-		method()
-	}
+	c.callMethod("Update")
 }
 
 // LateUpdate 帧迟滞更新（Late Update）
 func (c *ComponentStateEnableUpdateAndLateUpdate) LateUpdate() {
-	method, _ := c.bindMethod("LateUpdate").(func())
-	if method != nil {
-		method()
-	}
+	c.callMethod("LateUpdate")
 }
@@ -30,11 +30,12 @@ import (
 // EntityState 脚本化实体状态
 type EntityState struct {
 	framework.EntityBehavior
+	methods _ScriptMethods
 }
 
 // Callee 被调函数
 func (e *EntityState) Callee(method string) reflect.Value {
-	return reflect.ValueOf(e.bindMethod(method))
+	return e.scriptMethods().callee(method)
 }
 
 // Awake 生命周期唤醒（Awake）
@@ -47,18 +48,12 @@ func (e *EntityState) Awake() {
 		return
 	}
 
-	method, _ := e.bindMethod("Awake").(func())
-	if method != nil {
-		method()
-	}
+	e.callMethod("Awake")
 }
 
 // Start 生命周期开始（Start）
 func (e *EntityState) Start() {
-	method, _ := e.bindMethod("Start").(func())
-	if method != nil {
-		method()
-	}
+	e.callMethod("Start")
 
 	if e.State() != ec.EntityState_Starting {
 		return
@@ -71,6 +66,8 @@ func (e *EntityState) Start() {
 
 // Shut 生命周期结束（Shut）
 func (e *EntityState) Shut() {
+	defer e.methods.unsubscribe()
+
 	if cb, ok := e.Reflected().Interface().(LifecycleEntityOnStop); ok {
 		generic.CastAction0(cb.OnStop).Call(e.Runtime().AutoRecover(), e.Runtime().ReportError())
 	}
@@ -79,18 +76,13 @@ func (e *EntityState) Shut() {
 		return
 	}
 
-	method, _ := e.bindMethod("Shut").(func())
-	if method != nil {
-		method()
-	}
+	e.callMethod("Shut")
 }
 
 // Dispose 生命周期死亡（Death）
 func (e *EntityState) Dispose() {
-	method, _ := e.bindMethod("Dispose").(func())
-	if method != nil {
-		method()
-	}
+	e.callMethod("Dispose")
+	e.methods.release()
 
 	if cb, ok := e.Reflected().Interface().(LifecycleEntityOnDisposed); ok {
 		generic.CastAction0(cb.OnDisposed).Call(e.Runtime().AutoRecover(), e.Runtime().ReportError())
@@ -104,29 +96,19 @@ type EntityStateEnableUpdate struct {
 
 // Update 支持帧更新（Update）
 func (e *EntityStateEnableUpdate) Update() {
-	method, _ := e.bindMethod("Update").(func())
-	if method != nil {
-		method()
-	}
+	e.callMethod("Update")
 }
 
-func (e *EntityState) bindMethod(method string) any {
-	scriptPkg, ok := e.PT().Meta().Get("script_pkg")
-	if !ok {
-		return nil
-	}
-
-	scriptIdent, ok := e.PT().Meta().Get("script_ident")
-	if !ok {
-		return nil
-	}
+func (e *EntityState) callMethod(method string) {
+	e.scriptMethods().call(method)
+}
 
-	thisMethod := AddIn.Require(e.Service()).Solution().BindMethod(e.Reflected(), scriptPkg.(string), scriptIdent.(string), method)
-	if thisMethod == nil {
-		return nil
-	}
+func (e *EntityState) liveScriptMethods() *_ScriptMethods {
+	return e.methods.live()
+}
 
-	return thisMethod
+func (e *EntityState) scriptMethods() *_ScriptMethods {
+	return e.methods.init(e.Service(), e.Runtime(), e, e.PT().Meta(), e.Reflected(), nil)
 }
 
 // EntityStateEnableLateUpdate 脚本化实体状态，支持帧迟滞更新（Late Update）
@@ -136,10 +118,7 @@ type EntityStateEnableLateUpdate struct {
 
 // LateUpdate 帧迟滞更新（Late Update）
 func (e *EntityStateEnableLateUpdate) LateUpdate() {
-	method, _ := e.bindMethod("LateUpdate").(func())
-	if method != nil {
-		method()
-	}
+	e.callMethod("LateUpdate")
 }
 
 // EntityStateEnableUpdateAndLateUpdate 脚本化实体状态，支持帧更新（Update）、帧迟滞更新（Late Update）
@@ -149,16 +128,10 @@ type EntityStateEnableUpdateAndLateUpdate struct {
 
 // Update 帧更新（Update）
 func (e *EntityStateEnableUpdateAndLateUpdate) Update() {
-	method, _ := e.bindMethod("Update").(func())
-	if method != nil {
-		method()
-	}
+	e.callMethod("Update")
 }
 
 // LateUpdate 帧迟滞更新（Late Update）
 func (e *EntityStateEnableUpdateAndLateUpdate) LateUpdate() {
-	method, _ := e.bindMethod("LateUpdate").(func())
-	if method != nil {
-		method()
-	}
+	e.callMethod("LateUpdate")
 }
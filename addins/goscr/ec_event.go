@@ -0,0 +1,341 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package goscr
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/ec"
+	"git.golaxy.org/core/event"
+	"git.golaxy.org/core/runtime"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework"
+)
+
+// EventMethodPrefix 脚本事件处理方法前缀，脚本实现OnEvent_<Name>方法即订阅名为<Name>的事件，框架实体与运行时事件的<Name>为去除Event前缀的事件名，例如OnEvent_EntityDestroy
+const EventMethodPrefix = "OnEvent_"
+
+// TimerID 脚本定时器ID
+type TimerID int64
+
+// _ScriptTimer 脚本定时器，到期时按方法名调用脚本方法，热更新后调用新版本脚本
+type _ScriptTimer struct {
+	timer    *time.Timer
+	interval time.Duration
+	method   string
+	args     []any
+}
+
+// Emit 向实体发送事件，先调用实体脚本，再按组件声明顺序调用组件脚本中的OnEvent_<Name>方法，需要在实体所在的运行时中调用
+func Emit(entity ec.Entity, name string, args ...any) {
+	emitEntity(entity, name, args)
+}
+
+// EmitRuntime 向运行时发送事件，按实体首次脚本化的顺序调用运行时中所有脚本化实体与组件的OnEvent_<Name>方法，需要在运行时中调用
+func EmitRuntime(rt runtime.Context, name string, args ...any) {
+	script := AddIn.Require(framework.GetRuntime(rt).Service()).(*_Script)
+	script.emitRuntime(rt, name, args)
+}
+
+// iScriptMethods 获取存活的脚本方法表，EntityState与ComponentState实现
+type iScriptMethods interface {
+	liveScriptMethods() *_ScriptMethods
+}
+
+// emitEntity 向实体发送事件，先调用实体脚本，再按组件声明顺序调用组件脚本
+func emitEntity(entity ec.Entity, name string, args []any) {
+	rangeEntityScriptMethods(entity, func(ms *_ScriptMethods) {
+		ms.emit(name, args)
+	})
+}
+
+// rangeEntityScriptMethods 遍历实体与其组件中存活的脚本方法表，先实体后按声明顺序遍历组件
+func rangeEntityScriptMethods(entity ec.Entity, fun func(ms *_ScriptMethods)) {
+	if ms := liveScriptMethods(entity); ms != nil {
+		fun(ms)
+	}
+	entity.RangeComponents(func(comp ec.Component) bool {
+		if ms := liveScriptMethods(comp); ms != nil {
+			fun(ms)
+		}
+		return true
+	})
+}
+
+// emitRuntime 向运行时发送事件，按实体首次脚本化的顺序调用
+func (s *_Script) emitRuntime(rt runtime.Context, name string, args []any) {
+	for _, entity := range s.rangeLiveEntities(rt) {
+		emitEntity(entity, name, args)
+	}
+}
+
+func liveScriptMethods(v any) *_ScriptMethods {
+	sm, ok := v.(iScriptMethods)
+	if !ok {
+		return nil
+	}
+	return sm.liveScriptMethods()
+}
+
+// bindEntityEvents 订阅实体事件，转发至实体与其组件脚本中的OnEvent_<Name>方法，<Name>为去除Event前缀的事件名
+func bindEntityEvents(entity ec.Entity) []event.Handle {
+	return []event.Handle{
+		ec.BindEventEntityDestroy(entity, ec.HandleEventEntityDestroy(func(entity ec.Entity) {
+			emitEntity(entity, "EntityDestroy", []any{entity})
+		})),
+		ec.BindEventComponentManagerAddComponents(entity, ec.HandleEventComponentManagerAddComponents(func(entity ec.Entity, components []ec.Component) {
+			emitEntity(entity, "ComponentManagerAddComponents", []any{entity, components})
+		})),
+		ec.BindEventComponentManagerRemoveComponent(entity, ec.HandleEventComponentManagerRemoveComponent(func(entity ec.Entity, component ec.Component) {
+			emitEntity(entity, "ComponentManagerRemoveComponent", []any{entity, component})
+		})),
+		ec.BindEventComponentManagerComponentEnableChanged(entity, ec.HandleEventComponentManagerComponentEnableChanged(func(entity ec.Entity, component ec.Component, enable bool) {
+			emitEntity(entity, "ComponentManagerComponentEnableChanged", []any{entity, component, enable})
+		})),
+		ec.BindEventComponentManagerFirstTouchComponent(entity, ec.HandleEventComponentManagerFirstTouchComponent(func(entity ec.Entity, component ec.Component) {
+			emitEntity(entity, "ComponentManagerFirstTouchComponent", []any{entity, component})
+		})),
+		ec.BindEventTreeNodeAddChild(entity, ec.HandleEventTreeNodeAddChild(func(entity ec.Entity, childID uid.ID) {
+			emitEntity(entity, "TreeNodeAddChild", []any{entity, childID})
+		})),
+		ec.BindEventTreeNodeRemoveChild(entity, ec.HandleEventTreeNodeRemoveChild(func(entity ec.Entity, childID uid.ID) {
+			emitEntity(entity, "TreeNodeRemoveChild", []any{entity, childID})
+		})),
+		ec.BindEventTreeNodeAttachParent(entity, ec.HandleEventTreeNodeAttachParent(func(entity ec.Entity, parentID uid.ID) {
+			emitEntity(entity, "TreeNodeAttachParent", []any{entity, parentID})
+		})),
+		ec.BindEventTreeNodeDetachParent(entity, ec.HandleEventTreeNodeDetachParent(func(entity ec.Entity, parentID uid.ID) {
+			emitEntity(entity, "TreeNodeDetachParent", []any{entity, parentID})
+		})),
+		ec.BindEventTreeNodeMoveTo(entity, ec.HandleEventTreeNodeMoveTo(func(entity ec.Entity, fromParentID, toParentID uid.ID) {
+			emitEntity(entity, "TreeNodeMoveTo", []any{entity, fromParentID, toParentID})
+		})),
+	}
+}
+
+// bindRuntimeEvents 订阅运行时实体管理器事件，转发至运行时中所有脚本化实体与组件的OnEvent_<Name>方法，<Name>为去除Event前缀的事件名
+func (s *_Script) bindRuntimeEvents(rt runtime.Context) []event.Handle {
+	em := rt.EntityManager()
+	return []event.Handle{
+		runtime.BindEventEntityManagerAddEntity(em, runtime.HandleEventEntityManagerAddEntity(func(em runtime.EntityManager, entity ec.Entity) {
+			s.emitRuntime(rt, "EntityManagerAddEntity", []any{em, entity})
+		})),
+		runtime.BindEventEntityManagerRemoveEntity(em, runtime.HandleEventEntityManagerRemoveEntity(func(em runtime.EntityManager, entity ec.Entity) {
+			s.emitRuntime(rt, "EntityManagerRemoveEntity", []any{em, entity})
+		})),
+		runtime.BindEventEntityManagerEntityAddComponents(em, runtime.HandleEventEntityManagerEntityAddComponents(func(em runtime.EntityManager, entity ec.Entity, components []ec.Component) {
+			s.emitRuntime(rt, "EntityManagerEntityAddComponents", []any{em, entity, components})
+		})),
+		runtime.BindEventEntityManagerEntityRemoveComponent(em, runtime.HandleEventEntityManagerEntityRemoveComponent(func(em runtime.EntityManager, entity ec.Entity, component ec.Component) {
+			s.emitRuntime(rt, "EntityManagerEntityRemoveComponent", []any{em, entity, component})
+		})),
+		runtime.BindEventEntityManagerEntityComponentEnableChanged(em, runtime.HandleEventEntityManagerEntityComponentEnableChanged(func(em runtime.EntityManager, entity ec.Entity, component ec.Component, enable bool) {
+			s.emitRuntime(rt, "EntityManagerEntityComponentEnableChanged", []any{em, entity, component, enable})
+		})),
+		runtime.BindEventEntityManagerEntityFirstTouchComponent(em, runtime.HandleEventEntityManagerEntityFirstTouchComponent(func(em runtime.EntityManager, entity ec.Entity, component ec.Component) {
+			s.emitRuntime(rt, "EntityManagerEntityFirstTouchComponent", []any{em, entity, component})
+		})),
+	}
+}
+
+// emit 调用事件处理方法，脚本未实现时忽略
+func (ms *_ScriptMethods) emit(name string, args []any) {
+	ms.callWith(EventMethodPrefix+name, args)
+}
+
+// callWith 使用参数调用脚本方法，已结束或脚本未实现时忽略
+func (ms *_ScriptMethods) callWith(method string, args []any) {
+	if ms.shut || ms.solution().gen == nil {
+		return
+	}
+
+	thisMethod := ms.get(method)
+	if thisMethod == nil {
+		return
+	}
+	methodRV := reflect.ValueOf(thisMethod)
+
+	argsRV, err := makeCallArgs(methodRV.Type(), args)
+	if err != nil {
+		ms.fail(fmt.Errorf("goscr: script %s method %q, %s", ms.name, method, err))
+		return
+	}
+
+	ms.invoke(method, func() { methodRV.Call(argsRV) })
+}
+
+// makeCallArgs 转换调用参数，nil转换为参数类型的零值
+func makeCallArgs(methodRT reflect.Type, args []any) ([]reflect.Value, error) {
+	numIn := methodRT.NumIn()
+	if methodRT.IsVariadic() {
+		if len(args) < numIn-1 {
+			return nil, fmt.Errorf("args count %d less than %d", len(args), numIn-1)
+		}
+	} else if len(args) != numIn {
+		return nil, fmt.Errorf("args count %d mismatch %d", len(args), numIn)
+	}
+
+	argsRV := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var argRT reflect.Type
+		if methodRT.IsVariadic() && i >= numIn-1 {
+			argRT = methodRT.In(numIn - 1).Elem()
+		} else {
+			argRT = methodRT.In(i)
+		}
+
+		if arg == nil {
+			argsRV[i] = reflect.Zero(argRT)
+			continue
+		}
+
+		argRV := reflect.ValueOf(arg)
+		if !argRV.Type().AssignableTo(argRT) {
+			return nil, fmt.Errorf("arg %d type %s can't assign to %s", i, argRV.Type(), argRT)
+		}
+		argsRV[i] = argRV
+	}
+
+	return argsRV, nil
+}
+
+// after 创建定时器，interval大于0时重复触发
+func (ms *_ScriptMethods) after(d, interval time.Duration, method string, args []any) TimerID {
+	if ms.script == nil || ms.shut {
+		return 0
+	}
+
+	if ms.timers == nil {
+		ms.timers = map[TimerID]*_ScriptTimer{}
+	}
+
+	ms.timerSeq++
+	id := ms.timerSeq
+
+	t := &_ScriptTimer{
+		interval: interval,
+		method:   method,
+		args:     args,
+	}
+	t.timer = time.AfterFunc(d, func() {
+		ms.rt.Post(func(runtime.Context, ...any) { ms.fire(id) })
+	})
+	ms.timers[id] = t
+
+	return id
+}
+
+// fire 定时器到期，在运行时中调用
+func (ms *_ScriptMethods) fire(id TimerID) {
+	t, ok := ms.timers[id]
+	if !ok {
+		return
+	}
+
+	if t.interval > 0 {
+		t.timer.Reset(t.interval)
+	} else {
+		delete(ms.timers, id)
+	}
+
+	ms.callWith(t.method, t.args)
+}
+
+// stopTimer 停止定时器
+func (ms *_ScriptMethods) stopTimer(id TimerID) bool {
+	t, ok := ms.timers[id]
+	if !ok {
+		return false
+	}
+	t.timer.Stop()
+	delete(ms.timers, id)
+	return true
+}
+
+// unsubscribe 释放事件订阅与定时器，不再调用事件处理方法
+func (ms *_ScriptMethods) unsubscribe() {
+	ms.shut = true
+	for _, t := range ms.timers {
+		t.timer.Stop()
+	}
+	ms.timers = nil
+}
+
+// Emit 向所属实体发送事件，调用实体与其组件脚本中的OnEvent_<Name>方法
+func (c *ComponentState) Emit(name string, args ...any) {
+	Emit(c.Entity(), name, args...)
+}
+
+// EmitRuntime 向运行时发送事件，调用运行时中所有脚本化实体与组件的OnEvent_<Name>方法
+func (c *ComponentState) EmitRuntime(name string, args ...any) {
+	EmitRuntime(c.Runtime(), name, args...)
+}
+
+// After 延迟调用脚本方法，组件结束（Shut）时自动停止，热更新后调用新版本脚本
+func (c *ComponentState) After(d time.Duration, method string, args ...any) TimerID {
+	return c.scriptMethods().after(d, 0, method, args)
+}
+
+// Every 按间隔重复调用脚本方法，组件结束（Shut）时自动停止，热更新后调用新版本脚本
+func (c *ComponentState) Every(interval time.Duration, method string, args ...any) TimerID {
+	checkTimerInterval(interval)
+	return c.scriptMethods().after(interval, interval, method, args)
+}
+
+// StopTimer 停止定时器
+func (c *ComponentState) StopTimer(id TimerID) bool {
+	return c.methods.stopTimer(id)
+}
+
+// Emit 向实体发送事件，调用实体与其组件脚本中的OnEvent_<Name>方法
+func (e *EntityState) Emit(name string, args ...any) {
+	Emit(e, name, args...)
+}
+
+// EmitRuntime 向运行时发送事件，调用运行时中所有脚本化实体与组件的OnEvent_<Name>方法
+func (e *EntityState) EmitRuntime(name string, args ...any) {
+	EmitRuntime(e.Runtime(), name, args...)
+}
+
+// After 延迟调用脚本方法，实体结束（Shut）时自动停止，热更新后调用新版本脚本
+func (e *EntityState) After(d time.Duration, method string, args ...any) TimerID {
+	return e.scriptMethods().after(d, 0, method, args)
+}
+
+// Every 按间隔重复调用脚本方法，实体结束（Shut）时自动停止，热更新后调用新版本脚本
+func (e *EntityState) Every(interval time.Duration, method string, args ...any) TimerID {
+	checkTimerInterval(interval)
+	return e.scriptMethods().after(interval, interval, method, args)
+}
+
+// StopTimer 停止定时器
+func (e *EntityState) StopTimer(id TimerID) bool {
+	return e.methods.stopTimer(id)
+}
+
+func checkTimerInterval(interval time.Duration) {
+	if interval <= 0 {
+		exception.Panicf("goscr: %w: timer interval can't be set to a value less equal 0", core.ErrArgs)
+	}
+}
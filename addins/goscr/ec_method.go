@@ -0,0 +1,241 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package goscr
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"git.golaxy.org/core/ec"
+	"git.golaxy.org/core/runtime"
+	"git.golaxy.org/core/service"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/meta"
+	"git.golaxy.org/scaffold/addins/goscr/dynamic"
+)
+
+// _ScriptMethods 脚本方法表，固定绑定时的解决方案版本并缓存已绑定的方法，解决方案版本变化时迁移脚本状态并重新绑定
+type _ScriptMethods struct {
+	script   *_Script
+	rt       runtime.Context
+	owner    ec.Entity
+	meta     meta.Meta
+	this     reflect.Value
+	name     string
+	pinned   PinnedSolution
+	bound    map[string]any
+	released bool
+	disable  func()
+	disabled bool
+	failures int
+	inflight atomic.Pointer[_InflightCall]
+	shut     bool
+	timers   map[TimerID]*_ScriptTimer
+	timerSeq TimerID
+}
+
+// init 初始化，重复调用无效，owner为所属实体，disable用于连续调用失败时禁用，为nil时只停止调用脚本方法
+func (ms *_ScriptMethods) init(svcCtx service.Context, rt runtime.Context, owner ec.Entity, m meta.Meta, this reflect.Value, disable func()) *_ScriptMethods {
+	if ms.script != nil || ms.released {
+		return ms
+	}
+	ms.script = AddIn.Require(svcCtx).(*_Script)
+	ms.rt = rt
+	ms.owner = owner
+	ms.meta = m
+	ms.this = this
+	ms.name = scriptName(m)
+	ms.bound = map[string]any{}
+	ms.disable = disable
+	ms.script.addLive(ms)
+	return ms
+}
+
+// release 释放，解除固定的解决方案版本
+func (ms *_ScriptMethods) release() {
+	if ms.script == nil || ms.released {
+		return
+	}
+	ms.released = true
+	ms.unsubscribe()
+	ms.script.removeLive(ms)
+	ms.pinned.Unpin()
+	ms.pinned = PinnedSolution{}
+	ms.bound = nil
+}
+
+// live 未释放时返回自身，否则返回nil
+func (ms *_ScriptMethods) live() *_ScriptMethods {
+	if ms.script == nil || ms.released {
+		return nil
+	}
+	return ms
+}
+
+// solution 获取固定版本的解决方案，版本落后时先迁移脚本状态
+func (ms *_ScriptMethods) solution() PinnedSolution {
+	if ms.script == nil || ms.released {
+		return PinnedSolution{}
+	}
+
+	if ms.pinned.gen != nil && ms.pinned.Generation() == ms.script.Generation() {
+		return ms.pinned
+	}
+
+	prev := ms.pinned
+	ms.pinned = ms.script.PinSolution()
+	clear(ms.bound)
+
+	if prev.gen != nil {
+		ms.migrate(prev)
+		prev.Unpin()
+	}
+
+	return ms.pinned
+}
+
+// migrate 使用旧版本脚本的OnBeforeHotfix导出状态，再由新版本脚本的OnAfterHotfix导入
+func (ms *_ScriptMethods) migrate(prev PinnedSolution) {
+	var state any
+
+	onBefore, _ := bindScriptMethod(prev.Solution(), ms.meta, ms.this, "OnBeforeHotfix").(func() any)
+	if onBefore != nil {
+		ms.invoke("OnBeforeHotfix", func() { state = onBefore() })
+	}
+
+	onAfter, _ := ms.get("OnAfterHotfix").(func(any))
+	if onAfter != nil {
+		ms.invoke("OnAfterHotfix", func() { onAfter(state) })
+	}
+}
+
+// get 获取已绑定的脚本方法，未缓存时使用固定版本的解决方案绑定
+func (ms *_ScriptMethods) get(method string) any {
+	thisMethod, ok := ms.bound[method]
+	if !ok {
+		thisMethod = bindScriptMethod(ms.pinned.Solution(), ms.meta, ms.this, method)
+		ms.bound[method] = thisMethod
+	}
+	return thisMethod
+}
+
+// call 调用无参脚本方法
+func (ms *_ScriptMethods) call(method string) {
+	if ms.solution().gen == nil {
+		return
+	}
+
+	thisMethod, _ := ms.get(method).(func())
+	if thisMethod != nil {
+		ms.invoke(method, thisMethod)
+	}
+}
+
+// callee 创建被调函数，只有使用//rpc:export标记的脚本方法才能被调用，解决方案变化后重新绑定脚本方法
+func (ms *_ScriptMethods) callee(method string) reflect.Value {
+	if ms.solution().gen == nil || !ms.rpcExported(method) {
+		return reflect.Value{}
+	}
+
+	thisMethod := ms.get(method)
+	if thisMethod == nil {
+		return reflect.Value{}
+	}
+	methodRT := reflect.TypeOf(thisMethod)
+
+	return reflect.MakeFunc(methodRT, func(args []reflect.Value) []reflect.Value {
+		var thisMethod any
+		if ms.solution().gen != nil {
+			if !ms.rpcExported(method) {
+				exception.Panicf("goscr: script method %q not rpc exported", method)
+			}
+			thisMethod = ms.get(method)
+		}
+		if thisMethod == nil {
+			exception.Panicf("goscr: script method %q not found", method)
+		}
+
+		methodRV := reflect.ValueOf(thisMethod)
+		if methodRV.Type() != methodRT {
+			exception.Panicf("goscr: script method %q signature changed to %s", method, methodRV.Type())
+		}
+
+		var ret []reflect.Value
+		ok := ms.invoke(method, func() {
+			if methodRT.IsVariadic() {
+				ret = methodRV.CallSlice(args)
+			} else {
+				ret = methodRV.Call(args)
+			}
+		})
+		if !ok {
+			exception.Panicf("goscr: script method %q call failed", method)
+		}
+		return ret
+	})
+}
+
+// rpcExported 脚本方法在固定版本的解决方案中是否导出为RPC被调方法
+func (ms *_ScriptMethods) rpcExported(method string) bool {
+	scriptPkg, ok := ms.meta.Get("script_pkg")
+	if !ok {
+		return false
+	}
+
+	scriptIdent, ok := ms.meta.Get("script_ident")
+	if !ok {
+		return false
+	}
+
+	m := ms.pinned.Solution().Package(scriptPkg.(string)).Ident(scriptIdent.(string)).Method(method)
+	return m != nil && m.RPCExport
+}
+
+// scriptName 脚本名称，格式为<包路径>.<类型标识>
+func scriptName(m meta.Meta) string {
+	scriptPkg, _ := m.Get("script_pkg")
+	scriptIdent, _ := m.Get("script_ident")
+	return fmt.Sprintf("%v.%v", scriptPkg, scriptIdent)
+}
+
+// bindScriptMethod 使用解决方案绑定脚本成员方法
+func bindScriptMethod(solution *dynamic.Solution, m meta.Meta, this reflect.Value, method string) any {
+	if solution == nil {
+		return nil
+	}
+
+	scriptPkg, ok := m.Get("script_pkg")
+	if !ok {
+		return nil
+	}
+
+	scriptIdent, ok := m.Get("script_ident")
+	if !ok {
+		return nil
+	}
+
+	thisMethod := solution.BindMethod(this, scriptPkg.(string), scriptIdent.(string), method)
+	if thisMethod == nil {
+		return nil
+	}
+
+	return thisMethod
+}
@@ -3,30 +3,73 @@
 package fwlib
 
 import (
+	"context"
 	"git.golaxy.org/scaffold/addins/goscr/dynamic"
+	"go/constant"
+	"go/token"
 	"reflect"
 )
 
 func init() {
 	Symbols["git.golaxy.org/scaffold/addins/goscr/dynamic/dynamic"] = map[string]reflect.Value{
 		// function, constant and variable definitions
-		"Func":         reflect.ValueOf(dynamic.Func),
-		"NewCodeFs":    reflect.ValueOf(dynamic.NewCodeFs),
-		"NewScriptLib": reflect.ValueOf(dynamic.NewScriptLib),
-		"NewSolution":  reflect.ValueOf(dynamic.NewSolution),
-		"None":         reflect.ValueOf(dynamic.None),
-		"Struct":       reflect.ValueOf(dynamic.Struct),
+		"DefaultSourceTimeout":   reflect.ValueOf(dynamic.DefaultSourceTimeout),
+		"ErrIncrementalConflict": reflect.ValueOf(&dynamic.ErrIncrementalConflict).Elem(),
+		"ErrRemoteVerification":  reflect.ValueOf(&dynamic.ErrRemoteVerification).Elem(),
+		"ErrSolutionReleased":    reflect.ValueOf(&dynamic.ErrSolutionReleased).Elem(),
+		"ErrSymbolPolicy":        reflect.ValueOf(&dynamic.ErrSymbolPolicy).Elem(),
+		"Func":                   reflect.ValueOf(dynamic.Func),
+		"NewCodeFs":              reflect.ValueOf(dynamic.NewCodeFs),
+		"NewSandboxedSolution":   reflect.ValueOf(dynamic.NewSandboxedSolution),
+		"NewScriptLib":           reflect.ValueOf(dynamic.NewScriptLib),
+		"NewSolution":            reflect.ValueOf(dynamic.NewSolution),
+		"None":                   reflect.ValueOf(dynamic.None),
+		"RPCExportDirective":     reflect.ValueOf(constant.MakeFromLiteral("\"//rpc:export\"", token.STRING, 0)),
+		"Struct":                 reflect.ValueOf(dynamic.Struct),
 
 		// type definitions
-		"BindMode":     reflect.ValueOf((*dynamic.BindMode)(nil)),
-		"CodeFs":       reflect.ValueOf((*dynamic.CodeFs)(nil)),
-		"Method":       reflect.ValueOf((*dynamic.Method)(nil)),
-		"MethodBinder": reflect.ValueOf((*dynamic.MethodBinder)(nil)),
-		"Project":      reflect.ValueOf((*dynamic.Project)(nil)),
-		"Script":       reflect.ValueOf((*dynamic.Script)(nil)),
-		"ScriptBundle": reflect.ValueOf((*dynamic.ScriptBundle)(nil)),
-		"ScriptLib":    reflect.ValueOf((*dynamic.ScriptLib)(nil)),
-		"Solution":     reflect.ValueOf((*dynamic.Solution)(nil)),
-		"This":         reflect.ValueOf((*dynamic.This)(nil)),
+		"BindMode":            reflect.ValueOf((*dynamic.BindMode)(nil)),
+		"CodeFs":              reflect.ValueOf((*dynamic.CodeFs)(nil)),
+		"GitSource":           reflect.ValueOf((*dynamic.GitSource)(nil)),
+		"LocalSource":         reflect.ValueOf((*dynamic.LocalSource)(nil)),
+		"Manifest":            reflect.ValueOf((*dynamic.Manifest)(nil)),
+		"ManifestSource":      reflect.ValueOf((*dynamic.ManifestSource)(nil)),
+		"Method":              reflect.ValueOf((*dynamic.Method)(nil)),
+		"MethodBinder":        reflect.ValueOf((*dynamic.MethodBinder)(nil)),
+		"OCISource":           reflect.ValueOf((*dynamic.OCISource)(nil)),
+		"OverlaySource":       reflect.ValueOf((*dynamic.OverlaySource)(nil)),
+		"PolicyViolation":     reflect.ValueOf((*dynamic.PolicyViolation)(nil)),
+		"Project":             reflect.ValueOf((*dynamic.Project)(nil)),
+		"RemoteArchiveSource": reflect.ValueOf((*dynamic.RemoteArchiveSource)(nil)),
+		"Script":              reflect.ValueOf((*dynamic.Script)(nil)),
+		"ScriptBundle":        reflect.ValueOf((*dynamic.ScriptBundle)(nil)),
+		"ScriptLib":           reflect.ValueOf((*dynamic.ScriptLib)(nil)),
+		"Solution":            reflect.ValueOf((*dynamic.Solution)(nil)),
+		"SourceEvent":         reflect.ValueOf((*dynamic.SourceEvent)(nil)),
+		"SourceProvider":      reflect.ValueOf((*dynamic.SourceProvider)(nil)),
+		"SourceVersion":       reflect.ValueOf((*dynamic.SourceVersion)(nil)),
+		"SymbolPolicy":        reflect.ValueOf((*dynamic.SymbolPolicy)(nil)),
+		"This":                reflect.ValueOf((*dynamic.This)(nil)),
+
+		// interface wrapper definitions
+		"_SourceProvider": reflect.ValueOf((*_git_golaxy_org_scaffold_addins_goscr_dynamic_SourceProvider)(nil)),
 	}
 }
+
+// _git_golaxy_org_scaffold_addins_goscr_dynamic_SourceProvider is an interface wrapper for SourceProvider type
+type _git_golaxy_org_scaffold_addins_goscr_dynamic_SourceProvider struct {
+	IValue   interface{}
+	WFetch   func(ctx context.Context, codeFs *dynamic.CodeFs, scriptPath string) error
+	WVersion func(ctx context.Context) (string, error)
+	WWatch   func(ctx context.Context) (<-chan dynamic.SourceEvent, error)
+}
+
+func (W _git_golaxy_org_scaffold_addins_goscr_dynamic_SourceProvider) Fetch(ctx context.Context, codeFs *dynamic.CodeFs, scriptPath string) error {
+	return W.WFetch(ctx, codeFs, scriptPath)
+}
+func (W _git_golaxy_org_scaffold_addins_goscr_dynamic_SourceProvider) Version(ctx context.Context) (string, error) {
+	return W.WVersion(ctx)
+}
+func (W _git_golaxy_org_scaffold_addins_goscr_dynamic_SourceProvider) Watch(ctx context.Context) (<-chan dynamic.SourceEvent, error) {
+	return W.WWatch(ctx)
+}
@@ -3,23 +3,44 @@
 package fwlib
 
 import (
+	"context"
+	"git.golaxy.org/core/utils/uid"
 	"git.golaxy.org/scaffold/addins/goscr"
 	"git.golaxy.org/scaffold/addins/goscr/dynamic"
+	"go/constant"
+	"go/token"
 	"reflect"
 )
 
 func init() {
 	Symbols["git.golaxy.org/scaffold/addins/goscr/goscr"] = map[string]reflect.Value{
 		// function, constant and variable definitions
-		"AddIn":              reflect.ValueOf(&goscr.AddIn).Elem(),
-		"BuildEntityPT":      reflect.ValueOf(goscr.BuildEntityPT),
-		"ComponentScript":    reflect.ValueOf(goscr.ComponentScript),
-		"EntityScript":       reflect.ValueOf(goscr.EntityScript),
-		"GetComponentScript": reflect.ValueOf(goscr.GetComponentScript),
-		"GetEntityScript":    reflect.ValueOf(goscr.GetEntityScript),
-		"With":               reflect.ValueOf(&goscr.With).Elem(),
+		"AddIn":                     reflect.ValueOf(&goscr.AddIn).Elem(),
+		"AdminAddIn":                reflect.ValueOf(&goscr.AdminAddIn).Elem(),
+		"AdminWith":                 reflect.ValueOf(&goscr.AdminWith).Elem(),
+		"BuildEntityPT":             reflect.ValueOf(goscr.BuildEntityPT),
+		"ComponentLifecycleMethods": reflect.ValueOf(&goscr.ComponentLifecycleMethods).Elem(),
+		"ComponentScript":           reflect.ValueOf(goscr.ComponentScript),
+		"Emit":                      reflect.ValueOf(goscr.Emit),
+		"EmitRuntime":               reflect.ValueOf(goscr.EmitRuntime),
+		"EntityLifecycleMethods":    reflect.ValueOf(&goscr.EntityLifecycleMethods).Elem(),
+		"EntityScript":              reflect.ValueOf(goscr.EntityScript),
+		"ErrAdminAccessDenied":      reflect.ValueOf(&goscr.ErrAdminAccessDenied).Elem(),
+		"ErrAdminEvalPanicked":      reflect.ValueOf(&goscr.ErrAdminEvalPanicked).Elem(),
+		"ErrCallBudgetExceeded":     reflect.ValueOf(&goscr.ErrCallBudgetExceeded).Elem(),
+		"ErrClusterHotfixAborted":   reflect.ValueOf(&goscr.ErrClusterHotfixAborted).Elem(),
+		"ErrClusterHotfixDisabled":  reflect.ValueOf(&goscr.ErrClusterHotfixDisabled).Elem(),
+		"ErrNoSolutionHistory":      reflect.ValueOf(&goscr.ErrNoSolutionHistory).Elem(),
+		"ErrScriptNotFound":         reflect.ValueOf(&goscr.ErrScriptNotFound).Elem(),
+		"ErrSolutionNotLoaded":      reflect.ValueOf(&goscr.ErrSolutionNotLoaded).Elem(),
+		"EventMethodPrefix":         reflect.ValueOf(constant.MakeFromLiteral("\"OnEvent_\"", token.STRING, 0)),
+		"GetComponentScript":        reflect.ValueOf(goscr.GetComponentScript),
+		"GetEntityScript":           reflect.ValueOf(goscr.GetEntityScript),
+		"With":                      reflect.ValueOf(&goscr.With).Elem(),
 
 		// type definitions
+		"AdminOptions":                            reflect.ValueOf((*goscr.AdminOptions)(nil)),
+		"ClusterHotfixResult":                     reflect.ValueOf((*goscr.ClusterHotfixResult)(nil)),
 		"ComponentScriptBehavior":                 reflect.ValueOf((*goscr.ComponentScriptBehavior)(nil)),
 		"ComponentState":                          reflect.ValueOf((*goscr.ComponentState)(nil)),
 		"ComponentStateEnableLateUpdate":          reflect.ValueOf((*goscr.ComponentStateEnableLateUpdate)(nil)),
@@ -32,6 +53,7 @@ func init() {
 		"EntityStateEnableUpdate":                 reflect.ValueOf((*goscr.EntityStateEnableUpdate)(nil)),
 		"EntityStateEnableUpdateAndLateUpdate":    reflect.ValueOf((*goscr.EntityStateEnableUpdateAndLateUpdate)(nil)),
 		"IScript":                                 reflect.ValueOf((*goscr.IScript)(nil)),
+		"IScriptAdmin":                            reflect.ValueOf((*goscr.IScriptAdmin)(nil)),
 		"LifecycleComponentOnCreate":              reflect.ValueOf((*goscr.LifecycleComponentOnCreate)(nil)),
 		"LifecycleComponentOnDisposed":            reflect.ValueOf((*goscr.LifecycleComponentOnDisposed)(nil)),
 		"LifecycleComponentOnStarted":             reflect.ValueOf((*goscr.LifecycleComponentOnStarted)(nil)),
@@ -42,10 +64,15 @@ func init() {
 		"LifecycleEntityOnStop":                   reflect.ValueOf((*goscr.LifecycleEntityOnStop)(nil)),
 		"LoadedCB":                                reflect.ValueOf((*goscr.LoadedCB)(nil)),
 		"LoadingCB":                               reflect.ValueOf((*goscr.LoadingCB)(nil)),
+		"MethodMetrics":                           reflect.ValueOf((*goscr.MethodMetrics)(nil)),
+		"PinnedSolution":                          reflect.ValueOf((*goscr.PinnedSolution)(nil)),
 		"ScriptOptions":                           reflect.ValueOf((*goscr.ScriptOptions)(nil)),
+		"SmokeTestCB":                             reflect.ValueOf((*goscr.SmokeTestCB)(nil)),
+		"TimerID":                                 reflect.ValueOf((*goscr.TimerID)(nil)),
 
 		// interface wrapper definitions
 		"_IScript":                      reflect.ValueOf((*_git_golaxy_org_scaffold_addins_goscr_IScript)(nil)),
+		"_IScriptAdmin":                 reflect.ValueOf((*_git_golaxy_org_scaffold_addins_goscr_IScriptAdmin)(nil)),
 		"_LifecycleComponentOnCreate":   reflect.ValueOf((*_git_golaxy_org_scaffold_addins_goscr_LifecycleComponentOnCreate)(nil)),
 		"_LifecycleComponentOnDisposed": reflect.ValueOf((*_git_golaxy_org_scaffold_addins_goscr_LifecycleComponentOnDisposed)(nil)),
 		"_LifecycleComponentOnStarted":  reflect.ValueOf((*_git_golaxy_org_scaffold_addins_goscr_LifecycleComponentOnStarted)(nil)),
@@ -59,16 +86,58 @@ func init() {
 
 // _git_golaxy_org_scaffold_addins_goscr_IScript is an interface wrapper for IScript type
 type _git_golaxy_org_scaffold_addins_goscr_IScript struct {
-	IValue    interface{}
-	WHotfix   func() error
-	WSolution func() *dynamic.Solution
+	IValue         interface{}
+	WClusterHotfix func(ctx context.Context) (*goscr.ClusterHotfixResult, error)
+	WGeneration    func() int64
+	WHotfix        func() error
+	WMethodMetrics func() []goscr.MethodMetrics
+	WPinSolution   func() goscr.PinnedSolution
+	WRollback      func() error
+	WSolution      func() *dynamic.Solution
 }
 
-func (W _git_golaxy_org_scaffold_addins_goscr_IScript) Hotfix() error { return W.WHotfix() }
+func (W _git_golaxy_org_scaffold_addins_goscr_IScript) ClusterHotfix(ctx context.Context) (*goscr.ClusterHotfixResult, error) {
+	return W.WClusterHotfix(ctx)
+}
+func (W _git_golaxy_org_scaffold_addins_goscr_IScript) Generation() int64 { return W.WGeneration() }
+func (W _git_golaxy_org_scaffold_addins_goscr_IScript) Hotfix() error     { return W.WHotfix() }
+func (W _git_golaxy_org_scaffold_addins_goscr_IScript) MethodMetrics() []goscr.MethodMetrics {
+	return W.WMethodMetrics()
+}
+func (W _git_golaxy_org_scaffold_addins_goscr_IScript) PinSolution() goscr.PinnedSolution {
+	return W.WPinSolution()
+}
+func (W _git_golaxy_org_scaffold_addins_goscr_IScript) Rollback() error { return W.WRollback() }
 func (W _git_golaxy_org_scaffold_addins_goscr_IScript) Solution() *dynamic.Solution {
 	return W.WSolution()
 }
 
+// _git_golaxy_org_scaffold_addins_goscr_IScriptAdmin is an interface wrapper for IScriptAdmin type
+type _git_golaxy_org_scaffold_addins_goscr_IScriptAdmin struct {
+	IValue    interface{}
+	WEval     func(entityID uid.ID, service string, code string) (string, error)
+	WIdents   func(entityID uid.ID, service string, pkgPath string) ([]string, error)
+	WMethods  func(entityID uid.ID, service string, pkgPath string, ident string) ([]string, error)
+	WPackages func(entityID uid.ID, service string) ([]string, error)
+	WStatus   func(entityID uid.ID, service string) (int64, []string, error)
+}
+
+func (W _git_golaxy_org_scaffold_addins_goscr_IScriptAdmin) Eval(entityID uid.ID, service string, code string) (string, error) {
+	return W.WEval(entityID, service, code)
+}
+func (W _git_golaxy_org_scaffold_addins_goscr_IScriptAdmin) Idents(entityID uid.ID, service string, pkgPath string) ([]string, error) {
+	return W.WIdents(entityID, service, pkgPath)
+}
+func (W _git_golaxy_org_scaffold_addins_goscr_IScriptAdmin) Methods(entityID uid.ID, service string, pkgPath string, ident string) ([]string, error) {
+	return W.WMethods(entityID, service, pkgPath, ident)
+}
+func (W _git_golaxy_org_scaffold_addins_goscr_IScriptAdmin) Packages(entityID uid.ID, service string) ([]string, error) {
+	return W.WPackages(entityID, service)
+}
+func (W _git_golaxy_org_scaffold_addins_goscr_IScriptAdmin) Status(entityID uid.ID, service string) (int64, []string, error) {
+	return W.WStatus(entityID, service)
+}
+
 // _git_golaxy_org_scaffold_addins_goscr_LifecycleComponentOnCreate is an interface wrapper for LifecycleComponentOnCreate type
 type _git_golaxy_org_scaffold_addins_goscr_LifecycleComponentOnCreate struct {
 	IValue    interface{}
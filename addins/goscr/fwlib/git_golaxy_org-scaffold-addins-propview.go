@@ -3,11 +3,16 @@
 package fwlib
 
 import (
+	"context"
+	"git.golaxy.org/core/ec"
+	"git.golaxy.org/core/event"
 	"git.golaxy.org/core/utils/generic"
 	"git.golaxy.org/core/utils/meta"
 	"git.golaxy.org/core/utils/uid"
 	"git.golaxy.org/framework/net/gap/variant"
 	"git.golaxy.org/scaffold/addins/propview"
+	"go/constant"
+	"go/token"
 	"reflect"
 )
 
@@ -15,37 +20,86 @@ func init() {
 	Symbols["git.golaxy.org/scaffold/addins/propview/propview"] = map[string]reflect.Value{
 		// function, constant and variable definitions
 		"AddIn":                           reflect.ValueOf(&propview.AddIn).Elem(),
+		"BindEventPropChanged":            reflect.ValueOf(propview.BindEventPropChanged),
+		"DeclarePersistentProp":           reflect.ValueOf(propview.DeclarePersistentProp),
 		"DeclareProp":                     reflect.ValueOf(propview.DeclareProp),
+		"DefaultSyncHistorySize":          reflect.ValueOf(constant.MakeFromLiteral("64", token.INT, 0)),
 		"ErrDiscontinuousRevision":        reflect.ValueOf(&propview.ErrDiscontinuousRevision).Elem(),
 		"ErrEntityNoProp":                 reflect.ValueOf(&propview.ErrEntityNoProp).Elem(),
 		"ErrEntityNoPropTab":              reflect.ValueOf(&propview.ErrEntityNoPropTab).Elem(),
 		"ErrEntityNotFound":               reflect.ValueOf(&propview.ErrEntityNotFound).Elem(),
+		"ErrIncorrectSyncBatch":           reflect.ValueOf(&propview.ErrIncorrectSyncBatch).Elem(),
 		"ErrLoadFromServiceItself":        reflect.ValueOf(&propview.ErrLoadFromServiceItself).Elem(),
 		"ErrMethodNotFound":               reflect.ValueOf(&propview.ErrMethodNotFound).Elem(),
 		"ErrMethodParameterCountMismatch": reflect.ValueOf(&propview.ErrMethodParameterCountMismatch).Elem(),
 		"ErrMethodParameterTypeMismatch":  reflect.ValueOf(&propview.ErrMethodParameterTypeMismatch).Elem(),
 		"ErrOutdatedRevision":             reflect.ValueOf(&propview.ErrOutdatedRevision).Elem(),
+		"ErrPropDataNotFound":             reflect.ValueOf(&propview.ErrPropDataNotFound).Elem(),
 		"ErrSaveToServiceItself":          reflect.ValueOf(&propview.ErrSaveToServiceItself).Elem(),
+		"ErrStoreNotSet":                  reflect.ValueOf(&propview.ErrStoreNotSet).Elem(),
+		"HandleEventPropChanged":          reflect.ValueOf(propview.HandleEventPropChanged),
+		"NewMemPropStore":                 reflect.ValueOf(propview.NewMemPropStore),
+		"NewMongoPropStore":               reflect.ValueOf(propview.NewMongoPropStore),
+		"NewRedisPropStore":               reflect.ValueOf(propview.NewRedisPropStore),
+		"NewSQLPropStore":                 reflect.ValueOf(propview.NewSQLPropStore),
+		"OpSkip":                          reflect.ValueOf(constant.MakeFromLiteral("\"$skip\"", token.STRING, 0)),
+		"OpSnapshot":                      reflect.ValueOf(constant.MakeFromLiteral("\"$snapshot\"", token.STRING, 0)),
 		"ReferenceProp":                   reflect.ValueOf(propview.ReferenceProp),
 		"UnsafeProp":                      reflect.ValueOf(propview.UnsafeProp),
 		"UnsafePropSync":                  reflect.ValueOf(propview.UnsafePropSync),
+		"VisibleAll":                      reflect.ValueOf(propview.VisibleAll),
+		"VisibleOwner":                    reflect.ValueOf(propview.VisibleOwner),
+		"VisibleServer":                   reflect.ValueOf(propview.VisibleServer),
+		"With":                            reflect.ValueOf(&propview.With).Elem(),
 
 		// type definitions
-		"IProp":      reflect.ValueOf((*propview.IProp)(nil)),
-		"IPropSync":  reflect.ValueOf((*propview.IPropSync)(nil)),
-		"IPropTab":   reflect.ValueOf((*propview.IPropTab)(nil)),
-		"IPropView":  reflect.ValueOf((*propview.IPropView)(nil)),
-		"PropSyncer": reflect.ValueOf((*propview.PropSyncer)(nil)),
-		"PropTab":    reflect.ValueOf((*propview.PropTab)(nil)),
+		"EventPropChanged":        reflect.ValueOf((*propview.EventPropChanged)(nil)),
+		"EventPropChangedHandler": reflect.ValueOf((*propview.EventPropChangedHandler)(nil)),
+		"FlushPolicy":             reflect.ValueOf((*propview.FlushPolicy)(nil)),
+		"IProp":                   reflect.ValueOf((*propview.IProp)(nil)),
+		"IPropOpApplier":          reflect.ValueOf((*propview.IPropOpApplier)(nil)),
+		"IPropStore":              reflect.ValueOf((*propview.IPropStore)(nil)),
+		"IPropSync":               reflect.ValueOf((*propview.IPropSync)(nil)),
+		"IPropSyncEx":             reflect.ValueOf((*propview.IPropSyncEx)(nil)),
+		"IPropTab":                reflect.ValueOf((*propview.IPropTab)(nil)),
+		"IPropView":               reflect.ValueOf((*propview.IPropView)(nil)),
+		"ISyncPolicyProvider":     reflect.ValueOf((*propview.ISyncPolicyProvider)(nil)),
+		"MemPropStore":            reflect.ValueOf((*propview.MemPropStore)(nil)),
+		"MongoPropStore":          reflect.ValueOf((*propview.MongoPropStore)(nil)),
+		"OpArgError":              reflect.ValueOf((*propview.OpArgError)(nil)),
+		"PropMigration":           reflect.ValueOf((*propview.PropMigration)(nil)),
+		"PropRecord":              reflect.ValueOf((*propview.PropRecord)(nil)),
+		"PropSyncer":              reflect.ValueOf((*propview.PropSyncer)(nil)),
+		"PropTab":                 reflect.ValueOf((*propview.PropTab)(nil)),
+		"PropViewOptions":         reflect.ValueOf((*propview.PropViewOptions)(nil)),
+		"RedisPropStore":          reflect.ValueOf((*propview.RedisPropStore)(nil)),
+		"SQLPropStore":            reflect.ValueOf((*propview.SQLPropStore)(nil)),
+		"SyncPolicy":              reflect.ValueOf((*propview.SyncPolicy)(nil)),
+		"Visibility":              reflect.ValueOf((*propview.Visibility)(nil)),
 
 		// interface wrapper definitions
-		"_IProp":     reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_IProp)(nil)),
-		"_IPropSync": reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_IPropSync)(nil)),
-		"_IPropTab":  reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_IPropTab)(nil)),
-		"_IPropView": reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_IPropView)(nil)),
+		"_EventPropChanged":    reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_EventPropChanged)(nil)),
+		"_IProp":               reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_IProp)(nil)),
+		"_IPropOpApplier":      reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_IPropOpApplier)(nil)),
+		"_IPropStore":          reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_IPropStore)(nil)),
+		"_IPropSync":           reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_IPropSync)(nil)),
+		"_IPropSyncEx":         reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_IPropSyncEx)(nil)),
+		"_IPropTab":            reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_IPropTab)(nil)),
+		"_IPropView":           reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_IPropView)(nil)),
+		"_ISyncPolicyProvider": reflect.ValueOf((*_git_golaxy_org_scaffold_addins_propview_ISyncPolicyProvider)(nil)),
 	}
 }
 
+// _git_golaxy_org_scaffold_addins_propview_EventPropChanged is an interface wrapper for EventPropChanged type
+type _git_golaxy_org_scaffold_addins_propview_EventPropChanged struct {
+	IValue         interface{}
+	WOnPropChanged func(prop propview.IPropSync, op string, args []any, oldRevision int64, newRevision int64)
+}
+
+func (W _git_golaxy_org_scaffold_addins_propview_EventPropChanged) OnPropChanged(prop propview.IPropSync, op string, args []any, oldRevision int64, newRevision int64) {
+	W.WOnPropChanged(prop, op, args, oldRevision, newRevision)
+}
+
 // _git_golaxy_org_scaffold_addins_propview_IProp is an interface wrapper for IProp type
 type _git_golaxy_org_scaffold_addins_propview_IProp struct {
 	IValue          interface{}
@@ -72,6 +126,30 @@ func (W _git_golaxy_org_scaffold_addins_propview_IProp) VariantState() variant.V
 	return W.WVariantState()
 }
 
+// _git_golaxy_org_scaffold_addins_propview_IPropOpApplier is an interface wrapper for IPropOpApplier type
+type _git_golaxy_org_scaffold_addins_propview_IPropOpApplier struct {
+	IValue   interface{}
+	WApplyOp func(op string, args []reflect.Value) error
+}
+
+func (W _git_golaxy_org_scaffold_addins_propview_IPropOpApplier) ApplyOp(op string, args []reflect.Value) error {
+	return W.WApplyOp(op, args)
+}
+
+// _git_golaxy_org_scaffold_addins_propview_IPropStore is an interface wrapper for IPropStore type
+type _git_golaxy_org_scaffold_addins_propview_IPropStore struct {
+	IValue interface{}
+	WLoad  func(ctx context.Context, entityID uid.ID, prop string) ([]byte, int64, error)
+	WSave  func(ctx context.Context, entityID uid.ID, prop string, data []byte, revision int64) error
+}
+
+func (W _git_golaxy_org_scaffold_addins_propview_IPropStore) Load(ctx context.Context, entityID uid.ID, prop string) ([]byte, int64, error) {
+	return W.WLoad(ctx, entityID, prop)
+}
+func (W _git_golaxy_org_scaffold_addins_propview_IPropStore) Save(ctx context.Context, entityID uid.ID, prop string, data []byte, revision int64) error {
+	return W.WSave(ctx, entityID, prop, data, revision)
+}
+
 // _git_golaxy_org_scaffold_addins_propview_IPropSync is an interface wrapper for IPropSync type
 type _git_golaxy_org_scaffold_addins_propview_IPropSync struct {
 	IValue            interface{}
@@ -96,6 +174,52 @@ func (W _git_golaxy_org_scaffold_addins_propview_IPropSync) Save(service string)
 	return W.WSave(service)
 }
 
+// _git_golaxy_org_scaffold_addins_propview_IPropSyncEx is an interface wrapper for IPropSyncEx type
+type _git_golaxy_org_scaffold_addins_propview_IPropSyncEx struct {
+	IValue            interface{}
+	WEntity           func() ec.Entity
+	WEventPropChanged func() event.IEvent
+	WFlush            func() error
+	WLoad             func(service string) error
+	WManaged          func() propview.IProp
+	WMeta             func() *meta.Meta
+	WName             func() string
+	WReady            func() bool
+	WReflectedManaged func() reflect.Value
+	WRestore          func(cb func(err error))
+	WSave             func(service string) error
+	WSetSyncPolicy    func(policy *propview.SyncPolicy)
+	WSnapshot         func()
+}
+
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) Entity() ec.Entity { return W.WEntity() }
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) EventPropChanged() event.IEvent {
+	return W.WEventPropChanged()
+}
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) Flush() error { return W.WFlush() }
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) Load(service string) error {
+	return W.WLoad(service)
+}
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) Managed() propview.IProp {
+	return W.WManaged()
+}
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) Meta() *meta.Meta { return W.WMeta() }
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) Name() string     { return W.WName() }
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) Ready() bool      { return W.WReady() }
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) ReflectedManaged() reflect.Value {
+	return W.WReflectedManaged()
+}
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) Restore(cb func(err error)) {
+	W.WRestore(cb)
+}
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) Save(service string) error {
+	return W.WSave(service)
+}
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) SetSyncPolicy(policy *propview.SyncPolicy) {
+	W.WSetSyncPolicy(policy)
+}
+func (W _git_golaxy_org_scaffold_addins_propview_IPropSyncEx) Snapshot() { W.WSnapshot() }
+
 // _git_golaxy_org_scaffold_addins_propview_IPropTab is an interface wrapper for IPropTab type
 type _git_golaxy_org_scaffold_addins_propview_IPropTab struct {
 	IValue      interface{}
@@ -120,18 +244,48 @@ func (W _git_golaxy_org_scaffold_addins_propview_IPropTab) RangeProps(fun generi
 
 // _git_golaxy_org_scaffold_addins_propview_IPropView is an interface wrapper for IPropView type
 type _git_golaxy_org_scaffold_addins_propview_IPropView struct {
-	IValue interface{}
-	WLoad  func(entityID uid.ID, prop string, service string) ([]byte, int64, error)
-	WSave  func(entityID uid.ID, prop string, service string, data []byte, revision int64) error
-	WSync  func(entityID uid.ID, prop string, syncTo []string, revision int64, op string, args ...any)
+	IValue          interface{}
+	WFlush          func(entityID uid.ID, prop string, data []byte, revision int64, cb func(err error))
+	WLoad           func(entityID uid.ID, prop string, service string) ([]byte, int64, error)
+	WPersist        func(ps propview.IPropSyncEx)
+	WRestore        func(entityID uid.ID, prop string, cb func(data []byte, revision int64, err error))
+	WSave           func(entityID uid.ID, prop string, service string, data []byte, revision int64) error
+	WSync           func(entityID uid.ID, prop string, syncTo []string, revision int64, op string, args ...any)
+	WSyncSnapshot   func(entityID uid.ID, prop string, syncTo []string, policy *propview.SyncPolicy, revision int64, state variant.Value)
+	WSyncWithPolicy func(entityID uid.ID, prop string, syncTo []string, policy *propview.SyncPolicy, revision int64, op string, args ...any)
 }
 
+func (W _git_golaxy_org_scaffold_addins_propview_IPropView) Flush(entityID uid.ID, prop string, data []byte, revision int64, cb func(err error)) {
+	W.WFlush(entityID, prop, data, revision, cb)
+}
 func (W _git_golaxy_org_scaffold_addins_propview_IPropView) Load(entityID uid.ID, prop string, service string) ([]byte, int64, error) {
 	return W.WLoad(entityID, prop, service)
 }
+func (W _git_golaxy_org_scaffold_addins_propview_IPropView) Persist(ps propview.IPropSyncEx) {
+	W.WPersist(ps)
+}
+func (W _git_golaxy_org_scaffold_addins_propview_IPropView) Restore(entityID uid.ID, prop string, cb func(data []byte, revision int64, err error)) {
+	W.WRestore(entityID, prop, cb)
+}
 func (W _git_golaxy_org_scaffold_addins_propview_IPropView) Save(entityID uid.ID, prop string, service string, data []byte, revision int64) error {
 	return W.WSave(entityID, prop, service, data, revision)
 }
 func (W _git_golaxy_org_scaffold_addins_propview_IPropView) Sync(entityID uid.ID, prop string, syncTo []string, revision int64, op string, args ...any) {
 	W.WSync(entityID, prop, syncTo, revision, op, args...)
 }
+func (W _git_golaxy_org_scaffold_addins_propview_IPropView) SyncSnapshot(entityID uid.ID, prop string, syncTo []string, policy *propview.SyncPolicy, revision int64, state variant.Value) {
+	W.WSyncSnapshot(entityID, prop, syncTo, policy, revision, state)
+}
+func (W _git_golaxy_org_scaffold_addins_propview_IPropView) SyncWithPolicy(entityID uid.ID, prop string, syncTo []string, policy *propview.SyncPolicy, revision int64, op string, args ...any) {
+	W.WSyncWithPolicy(entityID, prop, syncTo, policy, revision, op, args...)
+}
+
+// _git_golaxy_org_scaffold_addins_propview_ISyncPolicyProvider is an interface wrapper for ISyncPolicyProvider type
+type _git_golaxy_org_scaffold_addins_propview_ISyncPolicyProvider struct {
+	IValue      interface{}
+	WSyncPolicy func() *propview.SyncPolicy
+}
+
+func (W _git_golaxy_org_scaffold_addins_propview_ISyncPolicyProvider) SyncPolicy() *propview.SyncPolicy {
+	return W.WSyncPolicy()
+}
@@ -21,11 +21,16 @@ package goscr
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"git.golaxy.org/core/ec"
+	"git.golaxy.org/core/runtime"
 	"git.golaxy.org/core/service"
 	"git.golaxy.org/core/utils/async"
 	"git.golaxy.org/core/utils/option"
@@ -33,7 +38,6 @@ import (
 	"git.golaxy.org/framework/addins/rpc/callpath"
 	"git.golaxy.org/scaffold/addins/goscr/dynamic"
 	"github.com/elliotchance/pie/v2"
-	"github.com/fsnotify/fsnotify"
 	"github.com/pangdogs/yaegi/stdlib"
 	"go.uber.org/zap"
 )
@@ -42,21 +46,47 @@ import (
 type IScript interface {
 	// Solution 解决方案
 	Solution() *dynamic.Solution
+	// Generation 解决方案版本号，每次替换解决方案时递增
+	Generation() int64
+	// PinSolution 获取并固定当前版本的解决方案，调用脚本方法期间需要固定，结束后调用Unpin解除固定
+	PinSolution() PinnedSolution
 	// Hotfix 热更新
 	Hotfix() error
+	// Rollback 回滚至上一个解决方案
+	Rollback() error
+	// MethodMetrics 脚本方法调用统计
+	MethodMetrics() []MethodMetrics
+	// ClusterHotfix 集群协同热更新，通知所有副本加载同一版本的解决方案，全部就绪后才同时替换
+	ClusterHotfix(ctx context.Context) (*ClusterHotfixResult, error)
 }
 
+// ErrNoSolutionHistory 没有可以回滚的历史解决方案
+var ErrNoSolutionHistory = errors.New("goscr: no solution history")
+
 func newScript(setting ...option.Setting[ScriptOptions]) IScript {
 	return &_Script{
-		options: option.New(With.Default(), setting...),
+		options:   option.New(With.Default(), setting...),
+		prepared:  map[string]*dynamic.Solution{},
+		committed: map[string]struct{}{},
 	}
 }
 
 type _Script struct {
 	svcCtx      service.Context
 	options     ScriptOptions
-	solution    *dynamic.Solution
+	current     atomic.Pointer[_SolutionGen]
+	generation  int64
+	history     []*_SolutionGen
+	historyMu   sync.Mutex
 	reloadingMu sync.Mutex
+	live        map[runtime.Context]*_LiveRuntime
+	liveMu      sync.Mutex
+	metrics     sync.Map
+	changed     []string
+	changedMu   sync.Mutex
+	prepared    map[string]*dynamic.Solution
+	committed   map[string]struct{}
+	preparedMu  sync.Mutex
 }
 
 // Init 初始化插件
@@ -65,21 +95,27 @@ func (s *_Script) Init(svcCtx service.Context) {
 
 	s.svcCtx = svcCtx
 
-	solution, err := s.loadSolution()
+	solution, err := s.loadSolution(nil)
 	if err != nil {
 		log.L(s.svcCtx).Panic("init load solution failed",
 			zap.String("pkg_root", s.options.PkgRoot),
 			zap.Error(err))
 	}
-	s.solution = solution
+	s.installSolution(solution)
 
 	log.L(s.svcCtx).Info("init load solution ok",
 		zap.String("pkg_root", s.options.PkgRoot),
 		zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)))
 
+	if s.options.ClusterHotfix {
+		s.listenCluster()
+	}
+
 	if s.options.AutoHotFix {
 		s.autoHotFix()
 	}
+
+	s.watchInflight()
 }
 
 // Shut 关闭插件
@@ -89,12 +125,41 @@ func (s *_Script) Shut(svcCtx service.Context) {
 
 // Solution 解决方案
 func (s *_Script) Solution() *dynamic.Solution {
-	return s.solution
+	gen := s.current.Load()
+	if gen == nil {
+		return nil
+	}
+	return gen.solution
+}
+
+// Generation 解决方案版本号，每次替换解决方案时递增
+func (s *_Script) Generation() int64 {
+	gen := s.current.Load()
+	if gen == nil {
+		return 0
+	}
+	return gen.generation
+}
+
+// PinSolution 获取并固定当前版本的解决方案，调用脚本方法期间需要固定，结束后调用Unpin解除固定
+func (s *_Script) PinSolution() PinnedSolution {
+	for {
+		gen := s.current.Load()
+		if gen == nil {
+			return PinnedSolution{}
+		}
+		if gen.pin() {
+			return PinnedSolution{gen: gen}
+		}
+	}
 }
 
 // Hotfix 热更新
 func (s *_Script) Hotfix() error {
-	solution, err := s.loadSolution()
+	s.reloadingMu.Lock()
+	defer s.reloadingMu.Unlock()
+
+	solution, err := s.reloadSolution()
 	if err != nil {
 		log.L(s.svcCtx).Error("hotfix load solution failed",
 			zap.String("pkg_root", s.options.PkgRoot),
@@ -102,7 +167,7 @@ func (s *_Script) Hotfix() error {
 			zap.Error(err))
 		return err
 	}
-	s.solution = solution
+	s.swapSolution(solution)
 
 	log.L(s.svcCtx).Info("hotfix load solution ok",
 		zap.String("pkg_root", s.options.PkgRoot),
@@ -110,16 +175,70 @@ func (s *_Script) Hotfix() error {
 	return nil
 }
 
-func (s *_Script) loadSolution() (*dynamic.Solution, error) {
-	solution := dynamic.NewSolution(s.options.PkgRoot)
+// Rollback 回滚至上一个解决方案
+func (s *_Script) Rollback() error {
+	s.reloadingMu.Lock()
+	defer s.reloadingMu.Unlock()
+
+	s.historyMu.Lock()
+	if len(s.history) <= 0 {
+		s.historyMu.Unlock()
+		return ErrNoSolutionHistory
+	}
+	solution := s.history[len(s.history)-1].solution
+	s.historyMu.Unlock()
+
+	if err := s.validateSolution(solution); err != nil {
+		log.L(s.svcCtx).Error("rollback validate solution failed",
+			zap.String("pkg_root", s.options.PkgRoot),
+			zap.Error(err))
+		return fmt.Errorf("validate solution failed, %s", err)
+	}
+
+	// 先发布回滚的解决方案，再移出历史，避免其版本排空时被释放
+	s.installSolution(solution)
+
+	s.historyMu.Lock()
+	s.history[len(s.history)-1] = nil
+	s.history = s.history[:len(s.history)-1]
+	history := len(s.history)
+	s.historyMu.Unlock()
+
+	log.L(s.svcCtx).Info("rollback solution ok",
+		zap.String("pkg_root", s.options.PkgRoot),
+		zap.Int("history", history))
+	return nil
+}
+
+// loadSolution 加载解决方案，prev不为nil时，开启增量编译的所有项目与使用远程清单的项目复用prev中不受changedPaths影响的脚本包
+func (s *_Script) loadSolution(prev *dynamic.Solution, changedPaths ...string) (*dynamic.Solution, error) {
+	var solution *dynamic.Solution
+	if s.options.SymbolPolicy != nil {
+		solution = dynamic.NewSandboxedSolution(s.options.PkgRoot, s.options.SymbolPolicy)
+	} else {
+		solution = dynamic.NewSolution(s.options.PkgRoot)
+	}
 	solution.Use(stdlib.Symbols)
 
+	// 使用远程清单的项目总是增量编译，只重新编译清单中摘要变化的文件所在的包
+	if s.options.IncrementalCompile {
+		solution.Incremental(prev, changedPaths...)
+	} else {
+		solution.IncrementalManifest(prev, changedPaths...)
+	}
+
 	if err := s.options.LoadingCB.SafeCall(solution); err != nil {
 		return nil, fmt.Errorf("loading callback error occurred, %s", err)
 	}
 
 	for _, project := range s.options.Projects {
-		if err := solution.Load(project); err != nil {
+		if err := s.loadProject(solution, project); err != nil {
+			if prev != nil && errors.Is(err, dynamic.ErrIncrementalConflict) {
+				log.L(s.svcCtx).Warn("incremental compile conflict, fall back to full compile",
+					zap.String("pkg_root", s.options.PkgRoot),
+					zap.Error(err))
+				return s.loadSolution(nil)
+			}
 			return nil, fmt.Errorf("load project failed, project:%s, %s", s.showProject(project), err)
 		}
 	}
@@ -135,146 +254,262 @@ func (s *_Script) loadSolution() (*dynamic.Solution, error) {
 	return solution, nil
 }
 
-func (s *_Script) autoHotFix() {
-	if pie.Any(s.options.Projects, func(project *dynamic.Project) bool { return project.LocalPath != "" }) {
-		watcher, err := fsnotify.NewWatcher()
+// loadProject 加载项目，获取源码版本与源码受SourceTimeout限制，服务停止时取消
+func (s *_Script) loadProject(solution *dynamic.Solution, project *dynamic.Project) error {
+	ctx, cancel := context.WithTimeout(s.svcCtx, s.options.SourceTimeout)
+	defer cancel()
+	return solution.LoadWithContext(ctx, project)
+}
+
+// reloadSolution 加载用于热更新的解决方案，校验与冒烟测试均通过后才能替换
+func (s *_Script) reloadSolution(changedPaths ...string) (*dynamic.Solution, error) {
+	solution, err := s.loadSolution(s.Solution(), changedPaths...)
+	if err != nil {
+		return nil, err
+	}
+
+	if reused := solution.ReusedPackages(); len(reused) > 0 {
+		log.L(s.svcCtx).Info("incremental compile reused unchanged script packages",
+			zap.String("pkg_root", s.options.PkgRoot),
+			zap.Strings("packages", reused))
+	}
+
+	if err := s.validateSolution(solution); err != nil {
+		return nil, fmt.Errorf("validate solution failed, %s", err)
+	}
+
+	if s.options.SmokeTestCB != nil {
+		err, panicErr := s.options.SmokeTestCB.SafeCall(solution)
+		if panicErr != nil {
+			err = panicErr
+		}
 		if err != nil {
-			log.L(s.svcCtx).Panic("auto hotfix watch solution local path changes failed",
-				zap.String("pkg_root", s.options.PkgRoot),
-				zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)),
-				zap.Error(err))
+			return nil, fmt.Errorf("smoke test failed, %s", err)
 		}
+	}
 
-		for _, project := range s.options.Projects {
-			if project.LocalPath != "" {
-				if err = watcher.Add(project.LocalPath); err != nil {
-					watcher.Close()
-					log.L(s.svcCtx).Panic("auto hotfix watch project local path changes failed",
-						zap.String("pkg_root", s.options.PkgRoot),
-						zap.String("project", s.showProject(project)),
-						zap.Error(err))
-				}
+	return solution, nil
+}
+
+// swapSolution 替换解决方案，保留历史解决方案，移出历史且已排空的解决方案立即释放
+func (s *_Script) swapSolution(solution *dynamic.Solution) {
+	if prev := s.current.Load(); s.options.SolutionHistorySize > 0 && prev != nil {
+		s.historyMu.Lock()
+		s.history = append(s.history, prev)
+		if len(s.history) > s.options.SolutionHistorySize {
+			evicted := s.history[0]
+			s.history[0] = nil
+			s.history = s.history[1:]
+			if evicted.released.Load() {
+				s.freeSolution(evicted)
 			}
 		}
+		s.historyMu.Unlock()
+	}
+	s.installSolution(solution)
+}
 
-		async.SpawnVoid(s.svcCtx.AsyncScope(), func(ctx context.Context) {
-			defer watcher.Close()
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case e, ok := <-watcher.Events:
-					if !ok {
-						return
-					}
+// installSolution 发布新版本的解决方案，旧版本在所有调用结束后释放
+func (s *_Script) installSolution(solution *dynamic.Solution) {
+	s.generation++
+	prev := s.current.Swap(newSolutionGen(solution, s.generation, s.drainedSolution))
+	if prev != nil {
+		prev.retire()
+		s.hotfixLive()
+	}
+}
 
-					log.L(s.svcCtx).Info("auto hotfix detecting solution local path changes, preparing to reload in delay_time",
-						zap.String("pkg_root", s.options.PkgRoot),
-						zap.String("file_path", e.Name),
-						zap.String("file_op", e.Op.String()),
-						zap.Duration("delay_time", s.options.AutoHotFixLocalDetectingDelayTime))
+// drainedSolution 旧版本的解决方案所有调用结束，不在历史中时释放，否则保留至移出历史
+func (s *_Script) drainedSolution(gen *_SolutionGen) {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
 
-					async.SpawnVoid(s.svcCtx.AsyncScope(), func(ctx context.Context) {
-						if !s.reloadingMu.TryLock() {
-							return
-						}
-						defer s.reloadingMu.Unlock()
+	if slices.ContainsFunc(s.history, func(h *_SolutionGen) bool { return h.solution == gen.solution }) {
+		log.L(s.svcCtx).Info("solution drained, kept in history",
+			zap.String("pkg_root", s.options.PkgRoot),
+			zap.Int64("generation", gen.generation))
+		return
+	}
+
+	s.freeSolution(gen)
+}
+
+// freeSolution 释放解决方案，当前正在使用或仍在历史中的解决方案不会释放
+func (s *_Script) freeSolution(gen *_SolutionGen) {
+	if gen.solution == s.Solution() || slices.ContainsFunc(s.history, func(h *_SolutionGen) bool { return h.solution == gen.solution }) {
+		return
+	}
+
+	if !gen.free() {
+		return
+	}
+
+	log.L(s.svcCtx).Info("solution drained, released",
+		zap.String("pkg_root", s.options.PkgRoot),
+		zap.Int64("generation", gen.generation))
+}
+
+func (s *_Script) autoHotFix() {
+	polling := false
+
+	for _, project := range s.options.Projects {
+		for _, provider := range project.SourceProviders() {
+			events, err := provider.Watch(s.svcCtx)
+			if err != nil {
+				log.L(s.svcCtx).Panic("auto hotfix watch project source changes failed",
+					zap.String("pkg_root", s.options.PkgRoot),
+					zap.String("project", s.showProject(project)),
+					zap.Error(err))
+			}
 
-						timer := time.NewTimer(s.options.AutoHotFixLocalDetectingDelayTime)
-						defer timer.Stop()
-						select {
-						case <-ctx.Done():
+			if events == nil {
+				polling = true
+				continue
+			}
+
+			async.SpawnVoid(s.svcCtx.AsyncScope(), func(ctx context.Context) {
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case e, ok := <-events:
+						if !ok {
 							return
-						case <-timer.C:
 						}
 
-						solution, err := s.loadSolution()
-						if err != nil {
-							log.L(s.svcCtx).Error("auto hotfix load solution failed",
+						if e.Err != nil {
+							log.L(s.svcCtx).Error("auto hotfix watch project source changes failed",
 								zap.String("pkg_root", s.options.PkgRoot),
-								zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)),
-								zap.Error(err))
-							return
+								zap.String("project", s.showProject(project)),
+								zap.Error(e.Err))
+							continue
 						}
-						s.solution = solution
 
-						log.L(s.svcCtx).Info("auto hotfix load solution ok",
+						log.L(s.svcCtx).Info("auto hotfix detecting project source changes, preparing to reload in delay_time",
 							zap.String("pkg_root", s.options.PkgRoot),
-							zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)))
-					})
+							zap.String("project", s.showProject(project)),
+							zap.String("file_path", e.Path),
+							zap.String("file_op", e.Op),
+							zap.Duration("delay_time", s.options.AutoHotFixLocalDetectingDelayTime))
 
-				case err, ok := <-watcher.Errors:
-					if !ok {
-						return
+						if e.Path != "" {
+							s.addChanged(path.Join(s.options.PkgRoot, project.ScriptRoot, e.Path))
+						}
+
+						async.SpawnVoid(s.svcCtx.AsyncScope(), func(ctx context.Context) {
+							if !s.reloadingMu.TryLock() {
+								return
+							}
+							defer s.reloadingMu.Unlock()
+
+							timer := time.NewTimer(s.options.AutoHotFixLocalDetectingDelayTime)
+							defer timer.Stop()
+							select {
+							case <-ctx.Done():
+								return
+							case <-timer.C:
+							}
+
+							s.autoReloadSolution()
+						})
 					}
-					log.L(s.svcCtx).Error("auto hotfix watch solution local path changes failed",
-						zap.String("pkg_root", s.options.PkgRoot),
-						zap.Error(err))
 				}
-			}
-		})
+			})
+		}
+	}
 
-		log.L(s.svcCtx).Info("auto hotfix watch solution local path changes ok",
-			zap.String("pkg_root", s.options.PkgRoot),
-			zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)))
+	log.L(s.svcCtx).Info("auto hotfix watch solution source changes ok",
+		zap.String("pkg_root", s.options.PkgRoot),
+		zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)),
+		zap.Bool("polling", polling))
+
+	if !polling {
+		return
 	}
 
-	if pie.Any(s.options.Projects, func(project *dynamic.Project) bool { return project.RemoteURL != "" }) {
-		async.SpawnVoid(s.svcCtx.AsyncScope(), func(ctx context.Context) {
-			ticker := time.NewTicker(s.options.AutoHotFixRemoteCheckingIntervalTime)
-			defer ticker.Stop()
+	async.SpawnVoid(s.svcCtx.AsyncScope(), func(ctx context.Context) {
+		ticker := time.NewTicker(s.options.AutoHotFixRemoteCheckingIntervalTime)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			b, err := s.detectChanged(ctx)
+			if err != nil {
+				log.L(s.svcCtx).Error("auto hotfix detect solution source changes failed",
+					zap.String("pkg_root", s.options.PkgRoot),
+					zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)),
+					zap.Error(err))
+				continue
+			}
+			if !b {
+				continue
+			}
 
-			for {
-				select {
-				case <-ctx.Done():
+			log.L(s.svcCtx).Info("auto hotfix detecting solution source changes, preparing to reload",
+				zap.String("pkg_root", s.options.PkgRoot))
+
+			func() {
+				if !s.reloadingMu.TryLock() {
 					return
-				case <-ticker.C:
 				}
+				defer s.reloadingMu.Unlock()
 
-				b, err := s.solution.DetectRemoteChanged()
-				if err != nil {
-					log.L(s.svcCtx).Error("auto hotfix detect solution remote path changes failed",
-						zap.String("pkg_root", s.options.PkgRoot),
-						zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)),
-						zap.Error(err))
-					continue
-				}
-				if !b {
-					continue
-				}
+				s.autoReloadSolution()
+			}()
+		}
+	})
+}
 
-				log.L(s.svcCtx).Info("auto hotfix detecting solution remote path changes, preparing to reload",
-					zap.String("pkg_root", s.options.PkgRoot))
+// detectChanged 检测当前解决方案的源码是否有变化，受SourceTimeout限制
+func (s *_Script) detectChanged(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.options.SourceTimeout)
+	defer cancel()
+	return s.Solution().DetectChanged(ctx)
+}
 
-				func() {
-					if !s.reloadingMu.TryLock() {
-						return
-					}
-					defer s.reloadingMu.Unlock()
+// addChanged 记录等待热更新的变化文件路径
+func (s *_Script) addChanged(filePath string) {
+	s.changedMu.Lock()
+	defer s.changedMu.Unlock()
+	s.changed = append(s.changed, filePath)
+}
 
-					solution, err := s.loadSolution()
-					if err != nil {
-						log.L(s.svcCtx).Error("auto hotfix load solution failed",
-							zap.String("pkg_root", s.options.PkgRoot),
-							zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)),
-							zap.Error(err))
-						return
-					}
-					s.solution = solution
+// takeChanged 取出所有等待热更新的变化文件路径
+func (s *_Script) takeChanged() []string {
+	s.changedMu.Lock()
+	defer s.changedMu.Unlock()
+	changed := s.changed
+	s.changed = nil
+	return changed
+}
 
-					log.L(s.svcCtx).Info("auto hotfix load solution ok",
-						zap.String("pkg_root", s.options.PkgRoot),
-						zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)))
-				}()
-			}
-		})
+func (s *_Script) autoReloadSolution() {
+	if s.options.ClusterHotfix {
+		s.autoClusterHotfix()
+		return
+	}
 
-		log.L(s.svcCtx).Info("auto hotfix watch solution remote path changes ok",
+	solution, err := s.reloadSolution(s.takeChanged()...)
+	if err != nil {
+		log.L(s.svcCtx).Error("auto hotfix load solution failed",
 			zap.String("pkg_root", s.options.PkgRoot),
-			zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)))
+			zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)),
+			zap.Error(err))
+		return
 	}
+	s.swapSolution(solution)
+
+	log.L(s.svcCtx).Info("auto hotfix load solution ok",
+		zap.String("pkg_root", s.options.PkgRoot),
+		zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)))
 }
 
+// cacheCallPath 缓存脚本导出的RPC被调方法的调用路径
 func (s *_Script) cacheCallPath(solution *dynamic.Solution, entityPT ec.EntityPT) {
 	scriptPkg, ok := entityPT.Meta().Get("script_pkg")
 	if ok {
@@ -283,6 +518,9 @@ func (s *_Script) cacheCallPath(solution *dynamic.Solution, entityPT ec.EntityPT
 			script := solution.Package(scriptPkg.(string)).Ident(scriptIdent.(string))
 			if script != nil {
 				for _, method := range script.Methods {
+					if !method.RPCExport {
+						continue
+					}
 					callpath.Cache("", method.Name)
 				}
 			}
@@ -299,6 +537,9 @@ func (s *_Script) cacheCallPath(solution *dynamic.Solution, entityPT ec.EntityPT
 				script := solution.Package(scriptPkg.(string)).Ident(scriptIdent.(string))
 				if script != nil {
 					for _, method := range script.Methods {
+						if !method.RPCExport {
+							continue
+						}
 						callpath.Cache(comp.Name, method.Name)
 					}
 				}
@@ -315,5 +556,11 @@ func (s *_Script) showProject(project *dynamic.Project) string {
 	if project.RemoteURL != "" {
 		files = append(files, project.RemoteURL)
 	}
+	if project.ManifestURL != "" {
+		files = append(files, project.ManifestURL)
+	}
+	for _, provider := range project.Sources {
+		files = append(files, fmt.Sprintf("%T", provider))
+	}
 	return fmt.Sprintf("%s => %s", project.ScriptRoot, files)
 }
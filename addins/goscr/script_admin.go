@@ -0,0 +1,298 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package goscr
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"git.golaxy.org/core/runtime"
+	"git.golaxy.org/core/utils/option"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework"
+	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/framework/addins/rpc"
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/scaffold/addins/goscr/dynamic"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrAdminAccessDenied = variant.Errorln(-101, "goscr admin access denied")
+	ErrSolutionNotLoaded = variant.Errorln(-102, "goscr solution not loaded")
+	ErrScriptNotFound    = variant.Errorln(-103, "goscr script not found")
+	ErrAdminEvalPanicked = variant.Errorln(-104, "goscr admin eval panicked")
+)
+
+// IScriptAdmin 脚本管理插件接口，安装在运行时中，通过实体所在的运行时调用目标服务的管理接口
+type IScriptAdmin interface {
+	// Eval 在目标服务中实体所在的运行时执行代码，代码结果为func(runtime.Context) any时使用运行时上下文调用，目标服务未开启LiveEval时在一次性的解释器中执行
+	Eval(entityID uid.ID, service string, code string) (string, error)
+	// Packages 列出目标服务解决方案中的脚本包
+	Packages(entityID uid.ID, service string) ([]string, error)
+	// Idents 列出目标服务解决方案中脚本包的类型标识，空字符串表示包中的全局方法
+	Idents(entityID uid.ID, service string, pkgPath string) ([]string, error)
+	// Methods 列出目标服务解决方案中脚本的方法
+	Methods(entityID uid.ID, service string, pkgPath, ident string) ([]string, error)
+	// Status 查询目标服务解决方案的版本号与源码版本
+	Status(entityID uid.ID, service string) (int64, []string, error)
+}
+
+func newScriptAdmin(setting ...option.Setting[AdminOptions]) IScriptAdmin {
+	return &_ScriptAdmin{
+		options: option.New(AdminWith.Default(), setting...),
+	}
+}
+
+type _ScriptAdmin struct {
+	rt      framework.IRuntime
+	options AdminOptions
+}
+
+// Init 初始化插件
+func (m *_ScriptAdmin) Init(rtCtx runtime.Context) {
+	log.L(rtCtx).Info("initializing add-in", zap.String("name", AdminAddIn.Name))
+
+	m.rt = framework.GetRuntime(rtCtx)
+}
+
+// Shut 关闭插件
+func (m *_ScriptAdmin) Shut(rtCtx runtime.Context) {
+	log.L(rtCtx).Info("shutting down add-in", zap.String("name", AdminAddIn.Name))
+}
+
+// Eval 在目标服务中实体所在的运行时执行代码，代码结果为func(runtime.Context) any时使用运行时上下文调用
+func (m *_ScriptAdmin) Eval(entityID uid.ID, service string, code string) (string, error) {
+	return rpc.Assert2[string, error](rpc.ProxyRuntime(m.rt, entityID).RPC(service, AdminAddIn.Name, "DoEval", code))
+}
+
+// Packages 列出目标服务解决方案中的脚本包
+func (m *_ScriptAdmin) Packages(entityID uid.ID, service string) ([]string, error) {
+	return rpc.Assert2[[]string, error](rpc.ProxyRuntime(m.rt, entityID).RPC(service, AdminAddIn.Name, "DoPackages"))
+}
+
+// Idents 列出目标服务解决方案中脚本包的类型标识，空字符串表示包中的全局方法
+func (m *_ScriptAdmin) Idents(entityID uid.ID, service string, pkgPath string) ([]string, error) {
+	return rpc.Assert2[[]string, error](rpc.ProxyRuntime(m.rt, entityID).RPC(service, AdminAddIn.Name, "DoIdents", pkgPath))
+}
+
+// Methods 列出目标服务解决方案中脚本的方法
+func (m *_ScriptAdmin) Methods(entityID uid.ID, service string, pkgPath, ident string) ([]string, error) {
+	return rpc.Assert2[[]string, error](rpc.ProxyRuntime(m.rt, entityID).RPC(service, AdminAddIn.Name, "DoMethods", pkgPath, ident))
+}
+
+// Status 查询目标服务解决方案的版本号与源码版本
+func (m *_ScriptAdmin) Status(entityID uid.ID, service string) (int64, []string, error) {
+	return rpc.Assert3[int64, []string, error](rpc.ProxyRuntime(m.rt, entityID).RPC(service, AdminAddIn.Name, "DoStatus"))
+}
+
+func (m *_ScriptAdmin) DoEval(code string) (string, error) {
+	caller := m.rt.RPCStack().CallChain().Last()
+
+	result, err := m.eval(caller.Svc, code)
+	if err != nil {
+		log.L(m.rt).Error("do eval failed",
+			zap.String("code", code),
+			zap.String("caller_svc", caller.Svc),
+			zap.String("caller_addr", caller.Addr),
+			zap.Error(err))
+		return "", err
+	}
+
+	log.L(m.rt).Info("do eval ok",
+		zap.String("code", code),
+		zap.String("caller_svc", caller.Svc),
+		zap.String("caller_addr", caller.Addr))
+	return result, nil
+}
+
+func (m *_ScriptAdmin) DoPackages() ([]string, error) {
+	caller := m.rt.RPCStack().CallChain().Last()
+
+	var pkgPaths []string
+
+	err := m.withSolution(caller.Svc, func(solution *dynamic.Solution) error {
+		solution.Range(func(pkgPath string, _ dynamic.ScriptBundle) bool {
+			pkgPaths = append(pkgPaths, pkgPath)
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		log.L(m.rt).Error("do list packages failed",
+			zap.String("caller_svc", caller.Svc),
+			zap.String("caller_addr", caller.Addr),
+			zap.Error(err))
+		return nil, err
+	}
+
+	slices.Sort(pkgPaths)
+	return pkgPaths, nil
+}
+
+func (m *_ScriptAdmin) DoIdents(pkgPath string) ([]string, error) {
+	caller := m.rt.RPCStack().CallChain().Last()
+
+	var idents []string
+
+	err := m.withSolution(caller.Svc, func(solution *dynamic.Solution) error {
+		scriptBundle := solution.Package(pkgPath)
+		if scriptBundle == nil {
+			return ErrScriptNotFound
+		}
+		for ident := range scriptBundle {
+			idents = append(idents, ident)
+		}
+		return nil
+	})
+	if err != nil {
+		log.L(m.rt).Error("do list idents failed",
+			zap.String("pkg_path", pkgPath),
+			zap.String("caller_svc", caller.Svc),
+			zap.String("caller_addr", caller.Addr),
+			zap.Error(err))
+		return nil, err
+	}
+
+	slices.Sort(idents)
+	return idents, nil
+}
+
+func (m *_ScriptAdmin) DoMethods(pkgPath, ident string) ([]string, error) {
+	caller := m.rt.RPCStack().CallChain().Last()
+
+	var methods []string
+
+	err := m.withSolution(caller.Svc, func(solution *dynamic.Solution) error {
+		script := solution.Package(pkgPath).Ident(ident)
+		if script == nil {
+			return ErrScriptNotFound
+		}
+		for _, method := range script.Methods {
+			methods = append(methods, method.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		log.L(m.rt).Error("do list methods failed",
+			zap.String("pkg_path", pkgPath),
+			zap.String("ident", ident),
+			zap.String("caller_svc", caller.Svc),
+			zap.String("caller_addr", caller.Addr),
+			zap.Error(err))
+		return nil, err
+	}
+
+	return methods, nil
+}
+
+func (m *_ScriptAdmin) DoStatus() (int64, []string, error) {
+	caller := m.rt.RPCStack().CallChain().Last()
+
+	var generation int64
+	var versions []string
+
+	err := m.withPinned(caller.Svc, func(pinned PinnedSolution) error {
+		generation = pinned.Generation()
+		for _, sv := range pinned.Solution().SourceVersions() {
+			versions = append(versions, fmt.Sprintf("%s %T %s", sv.ScriptPath, sv.Provider, sv.Version))
+		}
+		return nil
+	})
+	if err != nil {
+		log.L(m.rt).Error("do query status failed",
+			zap.String("caller_svc", caller.Svc),
+			zap.String("caller_addr", caller.Addr),
+			zap.Error(err))
+		return 0, nil, err
+	}
+
+	return generation, versions, nil
+}
+
+// allowed 检查调用方服务是否在白名单中
+func (m *_ScriptAdmin) allowed(callerSvc string) error {
+	if callerSvc == "" || !slices.Contains(m.options.AllowedServices, callerSvc) {
+		return ErrAdminAccessDenied
+	}
+	return nil
+}
+
+// withPinned 检查调用方后固定当前版本的解决方案并执行
+func (m *_ScriptAdmin) withPinned(callerSvc string, fun func(pinned PinnedSolution) error) error {
+	if err := m.allowed(callerSvc); err != nil {
+		return err
+	}
+
+	pinned := AddIn.Require(m.rt.Service()).PinSolution()
+	defer pinned.Unpin()
+
+	if pinned.Solution() == nil {
+		return ErrSolutionNotLoaded
+	}
+
+	return fun(pinned)
+}
+
+func (m *_ScriptAdmin) withSolution(callerSvc string, fun func(solution *dynamic.Solution) error) error {
+	return m.withPinned(callerSvc, func(pinned PinnedSolution) error {
+		return fun(pinned.Solution())
+	})
+}
+
+func (m *_ScriptAdmin) eval(callerSvc, code string) (result string, err error) {
+	err = m.withSolution(callerSvc, func(solution *dynamic.Solution) (evalErr error) {
+		defer func() {
+			if panicInfo := recover(); panicInfo != nil {
+				evalErr = fmt.Errorf("%w: %v", ErrAdminEvalPanicked, panicInfo)
+			}
+		}()
+
+		var rv reflect.Value
+		var err error
+		if m.options.LiveEval {
+			rv, err = solution.Eval(code)
+		} else {
+			rv, err = solution.EvalIsolated(code)
+		}
+		if err != nil {
+			return err
+		}
+
+		result = formatEvalResult(m.rt, rv)
+		return nil
+	})
+	return result, err
+}
+
+// formatEvalResult 格式化执行结果，结果为func(runtime.Context) any时使用运行时上下文调用
+func formatEvalResult(rtCtx runtime.Context, rv reflect.Value) string {
+	if !rv.IsValid() || !rv.CanInterface() {
+		return ""
+	}
+
+	v := rv.Interface()
+	if fun, ok := v.(func(runtime.Context) any); ok {
+		v = fun(rtCtx)
+	}
+
+	return fmt.Sprintf("%v", v)
+}
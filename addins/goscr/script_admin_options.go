@@ -0,0 +1,56 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package goscr
+
+import (
+	"git.golaxy.org/core/utils/option"
+)
+
+// AdminOptions 脚本管理插件所有选项
+type AdminOptions struct {
+	AllowedServices []string // 允许调用管理接口的服务，为空表示拒绝所有调用
+	LiveEval        bool     // 在解决方案的解释器中执行代码，可以读取与修改脚本包的全局状态，定义的全局变量与函数会保留，关闭时在一次性的解释器中执行
+}
+
+var AdminWith _AdminOption
+
+type _AdminOption struct{}
+
+// Default 默认值
+func (_AdminOption) Default() option.Setting[AdminOptions] {
+	return func(options *AdminOptions) {
+		AdminWith.AllowedServices().Apply(options)
+		AdminWith.LiveEval(false).Apply(options)
+	}
+}
+
+// AllowedServices 允许调用管理接口的服务，为空表示拒绝所有调用
+func (_AdminOption) AllowedServices(services ...string) option.Setting[AdminOptions] {
+	return func(options *AdminOptions) {
+		options.AllowedServices = services
+	}
+}
+
+// LiveEval 在解决方案的解释器中执行代码，可以读取与修改脚本包的全局状态，定义的全局变量与函数会保留，关闭时在一次性的解释器中执行
+func (_AdminOption) LiveEval(b bool) option.Setting[AdminOptions] {
+	return func(options *AdminOptions) {
+		options.LiveEval = b
+	}
+}
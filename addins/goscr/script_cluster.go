@@ -0,0 +1,508 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package goscr
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework"
+	"git.golaxy.org/framework/addins/discovery"
+	"git.golaxy.org/framework/addins/log"
+	"git.golaxy.org/scaffold/addins/goscr/dynamic"
+	"github.com/elliotchance/pie/v2"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrClusterHotfixDisabled = errors.New("goscr: cluster hotfix disabled") // 未开启集群协同热更新
+	ErrClusterHotfixAborted  = errors.New("goscr: cluster hotfix aborted")  // 集群协同热更新中止，存在加载失败或未响应的副本
+)
+
+// ClusterHotfixResult 集群协同热更新结果
+type ClusterHotfixResult struct {
+	TxID      string            // 热更新事务ID
+	Version   string            // 解决方案版本
+	Ready     []uid.ID          // 已就绪的副本
+	Failed    map[uid.ID]string // 加载失败或未响应的副本，值为失败原因
+	Committed bool              // 是否已提交
+	Unacked   map[uid.ID]string // 提交后未确认替换的副本，值为原因
+}
+
+const (
+	_ClusterOpPrepare = "prepare" // 加载并校验解决方案
+	_ClusterOpReady   = "ready"   // 副本已就绪
+	_ClusterOpFailed  = "failed"  // 副本加载失败
+	_ClusterOpCommit  = "commit"  // 提交，替换解决方案
+	_ClusterOpAbort   = "abort"   // 中止，丢弃已加载的解决方案
+	_ClusterOpAcked   = "acked"   // 副本已替换解决方案
+)
+
+// _ClusterMsg 集群协同热更新消息
+type _ClusterMsg struct {
+	Op      string `json:"op"`
+	TxID    string `json:"tx_id"`
+	Node    uid.ID `json:"node"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ClusterHotfix 集群协同热更新，本节点作为协调者加载解决方案后通知所有副本加载同一版本，全部就绪后提交，否则中止
+func (s *_Script) ClusterHotfix(ctx context.Context) (*ClusterHotfixResult, error) {
+	if !s.options.ClusterHotfix {
+		return nil, ErrClusterHotfixDisabled
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s.reloadingMu.Lock()
+	defer s.reloadingMu.Unlock()
+
+	return s.clusterHotfix(ctx)
+}
+
+// clusterHotfix 集群协同热更新，调用前需要锁定reloadingMu
+func (s *_Script) clusterHotfix(ctx context.Context) (*ClusterHotfixResult, error) {
+	svc := framework.GetService(s.svcCtx)
+	self := svc.ID()
+
+	solution, err := s.reloadSolution(s.takeChanged()...)
+	if err != nil {
+		log.L(s.svcCtx).Error("cluster hotfix load solution failed",
+			zap.String("pkg_root", s.options.PkgRoot),
+			zap.Error(err))
+		return nil, err
+	}
+
+	result := &ClusterHotfixResult{
+		TxID:    uid.New().String(),
+		Version: solutionVersion(solution),
+		Ready:   []uid.ID{self},
+		Failed:  map[uid.ID]string{},
+		Unacked: map[uid.ID]string{},
+	}
+
+	service, err := svc.Registry().Get(ctx, svc.Name())
+	if err != nil {
+		return nil, fmt.Errorf("get service %q nodes failed, %s", svc.Name(), err)
+	}
+
+	pending := map[uid.ID]struct{}{}
+	for _, node := range service.Nodes {
+		if node.ID != self {
+			pending[node.ID] = struct{}{}
+		}
+	}
+
+	// 回复订阅需要持续到提交确认结束，不受调用方ctx影响
+	repliesCtx, cancelReplies := context.WithCancel(s.svcCtx)
+	defer cancelReplies()
+
+	replies, err := svc.Broker().SubscribeEvent(repliesCtx, s.clusterTopic(result.TxID), "")
+	if err != nil {
+		return nil, fmt.Errorf("subscribe cluster hotfix replies failed, %s", err)
+	}
+
+	prepareCtx, cancel := context.WithTimeout(ctx, s.options.ClusterHotfixTimeout)
+	defer cancel()
+
+	err = s.publishCluster(prepareCtx, s.clusterTopic(), &_ClusterMsg{
+		Op:      _ClusterOpPrepare,
+		TxID:    result.TxID,
+		Node:    self,
+		Version: result.Version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.L(s.svcCtx).Info("cluster hotfix preparing",
+		zap.String("tx_id", result.TxID),
+		zap.String("version", result.Version),
+		zap.Int("replicas", len(pending)))
+
+loop:
+	for len(pending) > 0 {
+		select {
+		case <-prepareCtx.Done():
+			break loop
+		case e, ok := <-replies:
+			if !ok {
+				break loop
+			}
+
+			msg := &_ClusterMsg{}
+			if err := json.Unmarshal(e.Message, msg); err != nil || msg.TxID != result.TxID {
+				continue
+			}
+
+			if _, ok := pending[msg.Node]; !ok {
+				continue
+			}
+			delete(pending, msg.Node)
+
+			switch msg.Op {
+			case _ClusterOpReady:
+				result.Ready = append(result.Ready, msg.Node)
+			default:
+				result.Failed[msg.Node] = msg.Error
+			}
+		}
+	}
+
+	for node := range pending {
+		result.Failed[node] = "prepare timed out"
+	}
+
+	if len(result.Failed) > 0 {
+		if err := s.publishCluster(s.svcCtx, s.clusterTopic(), &_ClusterMsg{Op: _ClusterOpAbort, TxID: result.TxID, Node: self}); err != nil {
+			// 中止消息发送失败时副本会在超时后丢弃已加载的解决方案
+			log.L(s.svcCtx).Error("cluster hotfix publish abort failed",
+				zap.String("tx_id", result.TxID),
+				zap.Error(err))
+		}
+
+		log.L(s.svcCtx).Error("cluster hotfix aborted",
+			zap.String("tx_id", result.TxID),
+			zap.String("version", result.Version),
+			zap.Any("ready", result.Ready),
+			zap.Any("failed", result.Failed))
+		return result, fmt.Errorf("%w: %d replicas not ready", ErrClusterHotfixAborted, len(result.Failed))
+	}
+
+	s.swapSolution(solution)
+	result.Committed = true
+
+	// 等待所有就绪的副本确认替换，未确认前定期重发提交消息，超过ClusterHotfixTimeout仍未确认的副本记录至Unacked
+	pending = map[uid.ID]struct{}{}
+	for _, node := range result.Ready {
+		if node != self {
+			pending[node] = struct{}{}
+		}
+	}
+
+	commitCtx, cancelCommit := context.WithTimeout(s.svcCtx, s.options.ClusterHotfixTimeout)
+	defer cancelCommit()
+
+	resend := time.NewTicker(max(s.options.ClusterHotfixTimeout/10, 100*time.Millisecond))
+	defer resend.Stop()
+
+	publish := func() {
+		if err := s.publishCluster(commitCtx, s.clusterTopic(), &_ClusterMsg{Op: _ClusterOpCommit, TxID: result.TxID, Node: self}); err != nil {
+			log.L(s.svcCtx).Error("cluster hotfix publish commit failed",
+				zap.String("tx_id", result.TxID),
+				zap.Error(err))
+		}
+	}
+	publish()
+
+commitLoop:
+	for len(pending) > 0 {
+		select {
+		case <-commitCtx.Done():
+			break commitLoop
+		case <-resend.C:
+			publish()
+		case e, ok := <-replies:
+			if !ok {
+				break commitLoop
+			}
+
+			msg := &_ClusterMsg{}
+			if err := json.Unmarshal(e.Message, msg); err != nil || msg.TxID != result.TxID {
+				continue
+			}
+
+			if _, ok := pending[msg.Node]; !ok {
+				continue
+			}
+
+			switch msg.Op {
+			case _ClusterOpAcked:
+				delete(pending, msg.Node)
+			case _ClusterOpFailed:
+				delete(pending, msg.Node)
+				result.Unacked[msg.Node] = msg.Error
+			}
+		}
+	}
+
+	for node := range pending {
+		result.Unacked[node] = "commit ack timed out"
+	}
+
+	if len(result.Unacked) > 0 {
+		log.L(s.svcCtx).Error("cluster hotfix committed, but some replicas not acked",
+			zap.String("tx_id", result.TxID),
+			zap.String("version", result.Version),
+			zap.Any("ready", result.Ready),
+			zap.Any("unacked", result.Unacked))
+		return result, nil
+	}
+
+	log.L(s.svcCtx).Info("cluster hotfix committed",
+		zap.String("tx_id", result.TxID),
+		zap.String("version", result.Version),
+		zap.Any("ready", result.Ready))
+	return result, nil
+}
+
+// autoClusterHotfix 自动热更新使用集群协同热更新，只由服务中ID最小的节点发起，避免多个副本同时发起
+func (s *_Script) autoClusterHotfix() {
+	leader, err := s.isClusterLeader(s.svcCtx)
+	if err != nil {
+		log.L(s.svcCtx).Error("auto hotfix check cluster leader failed",
+			zap.String("pkg_root", s.options.PkgRoot),
+			zap.Error(err))
+		return
+	}
+
+	if !leader {
+		s.takeChanged()
+		return
+	}
+
+	if _, err := s.clusterHotfix(s.svcCtx); err != nil {
+		log.L(s.svcCtx).Error("auto hotfix cluster hotfix failed",
+			zap.String("pkg_root", s.options.PkgRoot),
+			zap.Strings("projects", pie.Of(s.options.Projects).StringsUsing(s.showProject)),
+			zap.Error(err))
+	}
+}
+
+// listenCluster 监听集群协同热更新消息，作为副本加载、提交或丢弃协调者通知的解决方案
+func (s *_Script) listenCluster() {
+	svc := framework.GetService(s.svcCtx)
+
+	events, err := svc.Broker().SubscribeEvent(s.svcCtx, s.clusterTopic(), "")
+	if err != nil {
+		log.L(s.svcCtx).Panic("cluster hotfix subscribe failed",
+			zap.String("topic", s.clusterTopic()),
+			zap.Error(err))
+	}
+
+	async.SpawnVoid(s.svcCtx.AsyncScope(), func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+
+				msg := &_ClusterMsg{}
+				if err := json.Unmarshal(e.Message, msg); err != nil {
+					log.L(s.svcCtx).Error("cluster hotfix decode message failed", zap.Error(err))
+					continue
+				}
+
+				// 协调者在发起时已经加载，提交时直接替换
+				if msg.Node == svc.ID() {
+					continue
+				}
+
+				switch msg.Op {
+				case _ClusterOpPrepare:
+					s.prepareCluster(ctx, msg)
+				case _ClusterOpCommit:
+					s.commitCluster(ctx, msg)
+				case _ClusterOpAbort:
+					s.discardPrepared(msg.TxID)
+				}
+			}
+		}
+	})
+}
+
+// prepareCluster 加载并校验协调者通知的解决方案版本，回复是否就绪
+func (s *_Script) prepareCluster(ctx context.Context, msg *_ClusterMsg) {
+	solution, err := func() (*dynamic.Solution, error) {
+		s.reloadingMu.Lock()
+		defer s.reloadingMu.Unlock()
+		return s.reloadSolution()
+	}()
+	if err == nil {
+		if version := solutionVersion(solution); version != msg.Version {
+			err = fmt.Errorf("solution version mismatch, expected %q, got %q", msg.Version, version)
+		}
+	}
+
+	reply := &_ClusterMsg{
+		Op:   _ClusterOpReady,
+		TxID: msg.TxID,
+		Node: framework.GetService(s.svcCtx).ID(),
+	}
+
+	if err != nil {
+		reply.Op = _ClusterOpFailed
+		reply.Error = err.Error()
+
+		log.L(s.svcCtx).Error("cluster hotfix prepare failed",
+			zap.String("tx_id", msg.TxID),
+			zap.String("version", msg.Version),
+			zap.String("coordinator", msg.Node.String()),
+			zap.Error(err))
+	} else {
+		s.preparedMu.Lock()
+		s.prepared[msg.TxID] = solution
+		s.preparedMu.Unlock()
+
+		// 协调者未送达提交或中止消息时，超时后丢弃，协调者重发提交消息的时间不超过该时间，同时清理已提交的事务记录
+		time.AfterFunc(2*s.options.ClusterHotfixTimeout, func() { s.discardPrepared(msg.TxID) })
+
+		log.L(s.svcCtx).Info("cluster hotfix prepared",
+			zap.String("tx_id", msg.TxID),
+			zap.String("version", msg.Version),
+			zap.String("coordinator", msg.Node.String()))
+	}
+
+	if err := s.publishCluster(ctx, s.clusterTopic(msg.TxID), reply); err != nil {
+		log.L(s.svcCtx).Error("cluster hotfix reply failed",
+			zap.String("tx_id", msg.TxID),
+			zap.Error(err))
+	}
+}
+
+// commitCluster 替换为已就绪的解决方案并回复确认，协调者重发的提交消息只回复确认
+func (s *_Script) commitCluster(ctx context.Context, msg *_ClusterMsg) {
+	reply := &_ClusterMsg{
+		Op:   _ClusterOpAcked,
+		TxID: msg.TxID,
+		Node: framework.GetService(s.svcCtx).ID(),
+	}
+
+	if err := s.commitPrepared(msg.TxID); err != nil {
+		reply.Op = _ClusterOpFailed
+		reply.Error = err.Error()
+
+		log.L(s.svcCtx).Error("cluster hotfix commit failed",
+			zap.String("tx_id", msg.TxID),
+			zap.String("coordinator", msg.Node.String()),
+			zap.Error(err))
+	}
+
+	if err := s.publishCluster(ctx, s.clusterTopic(msg.TxID), reply); err != nil {
+		log.L(s.svcCtx).Error("cluster hotfix ack failed",
+			zap.String("tx_id", msg.TxID),
+			zap.Error(err))
+	}
+}
+
+// commitPrepared 替换为已就绪的解决方案，事务已提交时不重复替换
+func (s *_Script) commitPrepared(txID string) error {
+	s.preparedMu.Lock()
+	if _, ok := s.committed[txID]; ok {
+		s.preparedMu.Unlock()
+		return nil
+	}
+	solution := s.prepared[txID]
+	if solution == nil {
+		s.preparedMu.Unlock()
+		return errors.New("solution not prepared")
+	}
+	delete(s.prepared, txID)
+	s.committed[txID] = struct{}{}
+	s.preparedMu.Unlock()
+
+	s.reloadingMu.Lock()
+	defer s.reloadingMu.Unlock()
+
+	s.swapSolution(solution)
+
+	log.L(s.svcCtx).Info("cluster hotfix committed",
+		zap.String("tx_id", txID))
+	return nil
+}
+
+func (s *_Script) takePrepared(txID string) *dynamic.Solution {
+	s.preparedMu.Lock()
+	defer s.preparedMu.Unlock()
+
+	solution := s.prepared[txID]
+	delete(s.prepared, txID)
+	return solution
+}
+
+func (s *_Script) discardPrepared(txID string) {
+	s.preparedMu.Lock()
+	delete(s.committed, txID)
+	s.preparedMu.Unlock()
+
+	if s.takePrepared(txID) != nil {
+		log.L(s.svcCtx).Info("cluster hotfix discarded prepared solution", zap.String("tx_id", txID))
+	}
+}
+
+// isClusterLeader 本节点是否为负责发起自动协同热更新的节点，选择服务中ID最小的节点
+func (s *_Script) isClusterLeader(ctx context.Context) (bool, error) {
+	svc := framework.GetService(s.svcCtx)
+
+	service, err := svc.Registry().Get(ctx, svc.Name())
+	if err != nil {
+		return false, fmt.Errorf("get service %q nodes failed, %s", svc.Name(), err)
+	}
+
+	if len(service.Nodes) <= 0 {
+		return true, nil
+	}
+
+	leader := slices.MinFunc(service.Nodes, func(a, b discovery.Node) int {
+		return cmp.Compare(a.ID.String(), b.ID.String())
+	})
+	return leader.ID == svc.ID(), nil
+}
+
+func (s *_Script) publishCluster(ctx context.Context, topic string, msg *_ClusterMsg) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal cluster hotfix message failed, %s", err)
+	}
+	if err := framework.GetService(s.svcCtx).Broker().Publish(ctx, topic, data); err != nil {
+		return fmt.Errorf("publish cluster hotfix message to %q failed, %s", topic, err)
+	}
+	return nil
+}
+
+// clusterTopic 集群协同热更新主题，格式为goscr<分隔符>hotfix<分隔符><服务名>[<分隔符><事务ID>]
+func (s *_Script) clusterTopic(txID ...string) string {
+	svc := framework.GetService(s.svcCtx)
+	return strings.Join(append([]string{"goscr", "hotfix", svc.Name()}, txID...), svc.Broker().Separator())
+}
+
+// solutionVersion 解决方案版本，由所有脚本路径的源码版本组成
+func solutionVersion(solution *dynamic.Solution) string {
+	var versions []string
+	for _, sv := range solution.SourceVersions() {
+		versions = append(versions, sv.ScriptPath+"@"+sv.Version)
+	}
+	slices.Sort(versions)
+	return strings.Join(versions, ";")
+}
@@ -0,0 +1,216 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package goscr
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/generic"
+	"git.golaxy.org/framework/addins/log"
+	"go.uber.org/zap"
+)
+
+// ErrCallBudgetExceeded 脚本方法调用超出时间预算
+var ErrCallBudgetExceeded = errors.New("goscr: script method call budget exceeded")
+
+// MethodMetrics 脚本方法调用统计
+type MethodMetrics struct {
+	Script   string        // 脚本，格式为<包路径>.<类型标识>
+	Method   string        // 方法名
+	Calls    int64         // 调用次数
+	Failures int64         // 失败次数，包含panic与超出时间预算
+	Total    time.Duration // 累计耗时
+	Max      time.Duration // 最大耗时
+}
+
+// Avg 平均耗时
+func (m MethodMetrics) Avg() time.Duration {
+	if m.Calls <= 0 {
+		return 0
+	}
+	return m.Total / time.Duration(m.Calls)
+}
+
+type _MethodKey struct {
+	script, method string
+}
+
+type _MethodStats struct {
+	calls, failures, total, max atomic.Int64
+}
+
+func (stats *_MethodStats) record(elapsed time.Duration, failed bool) {
+	stats.calls.Add(1)
+	if failed {
+		stats.failures.Add(1)
+	}
+	stats.total.Add(int64(elapsed))
+	for {
+		cur := stats.max.Load()
+		if int64(elapsed) <= cur || stats.max.CompareAndSwap(cur, int64(elapsed)) {
+			return
+		}
+	}
+}
+
+// MethodMetrics 脚本方法调用统计
+func (s *_Script) MethodMetrics() []MethodMetrics {
+	var metrics []MethodMetrics
+
+	s.metrics.Range(func(k, v any) bool {
+		key := k.(_MethodKey)
+		stats := v.(*_MethodStats)
+		metrics = append(metrics, MethodMetrics{
+			Script:   key.script,
+			Method:   key.method,
+			Calls:    stats.calls.Load(),
+			Failures: stats.failures.Load(),
+			Total:    time.Duration(stats.total.Load()),
+			Max:      time.Duration(stats.max.Load()),
+		})
+		return true
+	})
+
+	slices.SortFunc(metrics, func(a, b MethodMetrics) int {
+		return cmp.Or(cmp.Compare(a.Script, b.Script), cmp.Compare(a.Method, b.Method))
+	})
+
+	return metrics
+}
+
+func (s *_Script) methodStats(script, method string) *_MethodStats {
+	key := _MethodKey{script: script, method: method}
+	if v, ok := s.metrics.Load(key); ok {
+		return v.(*_MethodStats)
+	}
+	v, _ := s.metrics.LoadOrStore(key, &_MethodStats{})
+	return v.(*_MethodStats)
+}
+
+// _InflightCall 执行中的脚本方法调用，用于监控超出时间预算仍未返回的调用
+type _InflightCall struct {
+	method string
+	start  time.Time
+	warned atomic.Bool
+}
+
+// invoke 保护调用脚本方法，恢复panic并通过运行时报告错误，统计耗时，连续失败次数达到阈值时禁用
+func (ms *_ScriptMethods) invoke(method string, fun func()) bool {
+	if ms.disabled {
+		return false
+	}
+
+	call := &_InflightCall{method: method, start: time.Now()}
+	prev := ms.inflight.Swap(call)
+
+	panicErr := generic.CastAction0(fun).SafeCall()
+	elapsed := time.Since(call.start)
+
+	ms.inflight.Store(prev)
+
+	var err error
+	if panicErr != nil {
+		err = fmt.Errorf("goscr: script %s method %q panicked, %w", ms.name, method, panicErr)
+	} else if budget := ms.script.options.CallBudget; budget > 0 && elapsed > budget {
+		err = fmt.Errorf("%w: script %s method %q took %s, budget %s", ErrCallBudgetExceeded, ms.name, method, elapsed, budget)
+	}
+
+	ms.script.methodStats(ms.name, method).record(elapsed, err != nil)
+
+	if err != nil {
+		ms.fail(err)
+		return false
+	}
+
+	ms.failures = 0
+	return true
+}
+
+// fail 报告调用失败，连续失败次数达到阈值时禁用
+func (ms *_ScriptMethods) fail(err error) {
+	select {
+	case ms.rt.ReportError() <- err:
+	default:
+		log.L(ms.rt).Error("script method call failed", zap.Error(err))
+	}
+
+	ms.failures++
+
+	maxFailures := ms.script.options.MaxCallFailures
+	if maxFailures <= 0 || ms.failures < maxFailures {
+		return
+	}
+	ms.failures = 0
+
+	log.L(ms.rt).Error("script method call failed repeatedly, disabled",
+		zap.String("script", ms.name),
+		zap.Int("failures", maxFailures))
+
+	if ms.disable != nil {
+		ms.disable()
+	} else {
+		ms.disabled = true
+	}
+}
+
+// watchInflight 监控执行中的脚本方法调用，超出时间预算仍未返回时记录日志，Go无法中断执行中的脚本，只能协助定位问题
+func (s *_Script) watchInflight() {
+	budget := s.options.CallBudget
+	if budget <= 0 {
+		return
+	}
+
+	async.SpawnVoid(s.svcCtx.AsyncScope(), func(ctx context.Context) {
+		ticker := time.NewTicker(budget)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			s.liveMu.Lock()
+			for rt, lr := range s.live {
+				for ms := range lr.methods {
+					call := ms.inflight.Load()
+					if call == nil || time.Since(call.start) <= budget || !call.warned.CompareAndSwap(false, true) {
+						continue
+					}
+					log.L(s.svcCtx).Warn("script method call exceeds budget, still running",
+						zap.String("runtime", rt.String()),
+						zap.String("script", ms.name),
+						zap.String("method", call.method),
+						zap.Duration("elapsed", time.Since(call.start)),
+						zap.Duration("budget", budget))
+				}
+			}
+			s.liveMu.Unlock()
+		}
+	})
+}
@@ -0,0 +1,143 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package goscr
+
+import (
+	"slices"
+
+	"git.golaxy.org/core/ec"
+	"git.golaxy.org/core/event"
+	"git.golaxy.org/core/runtime"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/log"
+	"go.uber.org/zap"
+)
+
+// _LiveRuntime 运行时中存活的脚本化实体与组件，以及转发至脚本的运行时事件订阅
+type _LiveRuntime struct {
+	methods  map[*_ScriptMethods]struct{}
+	entities map[uid.ID]*_LiveEntity
+	order    []ec.Entity
+	handles  []event.Handle
+}
+
+// _LiveEntity 存活的脚本化实体，以及转发至脚本的实体事件订阅
+type _LiveEntity struct {
+	refs    int
+	handles []event.Handle
+}
+
+func (s *_Script) addLive(ms *_ScriptMethods) {
+	s.liveMu.Lock()
+	defer s.liveMu.Unlock()
+
+	if s.live == nil {
+		s.live = map[runtime.Context]*_LiveRuntime{}
+	}
+
+	lr, ok := s.live[ms.rt]
+	if !ok {
+		lr = &_LiveRuntime{
+			methods:  map[*_ScriptMethods]struct{}{},
+			entities: map[uid.ID]*_LiveEntity{},
+			handles:  s.bindRuntimeEvents(ms.rt),
+		}
+		s.live[ms.rt] = lr
+	}
+	lr.methods[ms] = struct{}{}
+
+	le, ok := lr.entities[ms.owner.ID()]
+	if !ok {
+		le = &_LiveEntity{
+			handles: bindEntityEvents(ms.owner),
+		}
+		lr.entities[ms.owner.ID()] = le
+		lr.order = append(lr.order, ms.owner)
+	}
+	le.refs++
+}
+
+func (s *_Script) removeLive(ms *_ScriptMethods) {
+	s.liveMu.Lock()
+	defer s.liveMu.Unlock()
+
+	lr, ok := s.live[ms.rt]
+	if !ok {
+		return
+	}
+
+	if _, ok := lr.methods[ms]; !ok {
+		return
+	}
+	delete(lr.methods, ms)
+
+	entityID := ms.owner.ID()
+
+	if le, ok := lr.entities[entityID]; ok {
+		le.refs--
+		if le.refs <= 0 {
+			event.UnbindHandles(le.handles)
+			delete(lr.entities, entityID)
+			lr.order = slices.DeleteFunc(lr.order, func(entity ec.Entity) bool { return entity.ID() == entityID })
+		}
+	}
+
+	if len(lr.methods) <= 0 {
+		event.UnbindHandles(lr.handles)
+		delete(s.live, ms.rt)
+	}
+}
+
+// rangeLiveEntities 获取运行时中存活的脚本化实体，按首次存活的顺序排列
+func (s *_Script) rangeLiveEntities(rt runtime.Context) []ec.Entity {
+	s.liveMu.Lock()
+	defer s.liveMu.Unlock()
+
+	lr, ok := s.live[rt]
+	if !ok {
+		return nil
+	}
+	return slices.Clone(lr.order)
+}
+
+// hotfixLive 通知所有运行时中存活的脚本化实体与组件迁移至新版本的解决方案，按实体首次脚本化的顺序迁移，同一实体先迁移实体再按声明顺序迁移组件
+func (s *_Script) hotfixLive() {
+	s.liveMu.Lock()
+	lives := make(map[runtime.Context][]ec.Entity, len(s.live))
+	for rt, lr := range s.live {
+		lives[rt] = slices.Clone(lr.order)
+	}
+	s.liveMu.Unlock()
+
+	for rt, entities := range lives {
+		err := rt.Post(func(runtime.Context, ...any) {
+			for _, entity := range entities {
+				rangeEntityScriptMethods(entity, func(ms *_ScriptMethods) {
+					ms.solution()
+				})
+			}
+		})
+		if err != nil {
+			log.L(s.svcCtx).Error("post hotfix to runtime failed",
+				zap.String("runtime", rt.String()),
+				zap.Error(err))
+		}
+	}
+}
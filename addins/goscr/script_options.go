@@ -34,17 +34,28 @@ type (
 	LoadingCB = generic.Action1[*dynamic.Solution]
 	// LoadedCB 加载完成回调
 	LoadedCB = generic.Action1[*dynamic.Solution]
+	// SmokeTestCB 热更新冒烟测试回调，返回错误时放弃热更新
+	SmokeTestCB = generic.Func1[*dynamic.Solution, error]
 )
 
 // ScriptOptions 所有选项
 type ScriptOptions struct {
-	PkgRoot                              string             // 包根路径
-	Projects                             []*dynamic.Project // 脚本工程列表
-	AutoHotFix                           bool               // 自动热更新
-	AutoHotFixLocalDetectingDelayTime    time.Duration      // 自动热更新本地脚本文件延迟更新时间
-	AutoHotFixRemoteCheckingIntervalTime time.Duration      // 自动热更新远端脚本文件检测间隔时间
-	LoadingCB                            LoadingCB          // 加载完成回调
-	LoadedCB                             LoadedCB           // 加载完成回调
+	PkgRoot                              string                // 包根路径
+	Projects                             []*dynamic.Project    // 脚本工程列表
+	AutoHotFix                           bool                  // 自动热更新
+	AutoHotFixLocalDetectingDelayTime    time.Duration         // 自动热更新本地脚本文件延迟更新时间
+	AutoHotFixRemoteCheckingIntervalTime time.Duration         // 自动热更新远端脚本文件检测间隔时间
+	LoadingCB                            LoadingCB             // 加载完成回调
+	LoadedCB                             LoadedCB              // 加载完成回调
+	SmokeTestCB                          SmokeTestCB           // 热更新冒烟测试回调
+	SolutionHistorySize                  int                   // 保留的历史解决方案数量，用于回滚
+	CallBudget                           time.Duration         // 脚本方法单次调用时间预算，超出时视为调用失败，为0表示不限制
+	MaxCallFailures                      int                   // 脚本方法连续调用失败次数阈值，达到后禁用组件或停止调用实体脚本方法，为0表示不禁用
+	SymbolPolicy                         *dynamic.SymbolPolicy // 符号访问策略，不为nil时沙箱化脚本，作用于所有导入的符号表与脚本代码
+	IncrementalCompile                   bool                  // 热更新时增量编译，只重新编译源码变化的包与通过导入关系与它们相连的包
+	ClusterHotfix                        bool                  // 集群协同热更新，所有副本加载并校验通过后才同时替换解决方案
+	ClusterHotfixTimeout                 time.Duration         // 集群协同热更新等待副本就绪的超时时间
+	SourceTimeout                        time.Duration         // 加载项目或检测源码变化时，获取源码版本与源码的超时时间
 }
 
 var With _Option
@@ -61,6 +72,15 @@ func (_Option) Default() option.Setting[ScriptOptions] {
 		With.AutoHotFixRemoteCheckingIntervalTime(time.Minute).Apply(options)
 		With.LoadingCB(nil).Apply(options)
 		With.LoadedCB(nil).Apply(options)
+		With.SmokeTestCB(nil).Apply(options)
+		With.SolutionHistorySize(3).Apply(options)
+		With.CallBudget(0).Apply(options)
+		With.MaxCallFailures(0).Apply(options)
+		With.SymbolPolicy(nil).Apply(options)
+		With.IncrementalCompile(false).Apply(options)
+		With.ClusterHotfix(false).Apply(options)
+		With.ClusterHotfixTimeout(30 * time.Second).Apply(options)
+		With.SourceTimeout(dynamic.DefaultSourceTimeout).Apply(options)
 	}
 }
 
@@ -99,6 +119,23 @@ func (_Option) LoadedCB(cb LoadedCB) option.Setting[ScriptOptions] {
 	}
 }
 
+// SmokeTestCB 热更新冒烟测试回调
+func (_Option) SmokeTestCB(cb SmokeTestCB) option.Setting[ScriptOptions] {
+	return func(options *ScriptOptions) {
+		options.SmokeTestCB = cb
+	}
+}
+
+// SolutionHistorySize 保留的历史解决方案数量，用于回滚
+func (_Option) SolutionHistorySize(size int) option.Setting[ScriptOptions] {
+	return func(options *ScriptOptions) {
+		if size < 0 {
+			exception.Panicf("goscr: %w: option SolutionHistorySize can't be set to a value less than 0", core.ErrArgs)
+		}
+		options.SolutionHistorySize = size
+	}
+}
+
 // AutoHotFixLocalDetectingDelayTime 自动热更新本地脚本文件延迟更新时间
 func (_Option) AutoHotFixLocalDetectingDelayTime(d time.Duration) option.Setting[ScriptOptions] {
 	return func(options *ScriptOptions) {
@@ -118,3 +155,65 @@ func (_Option) AutoHotFixRemoteCheckingIntervalTime(d time.Duration) option.Sett
 		options.AutoHotFixRemoteCheckingIntervalTime = d
 	}
 }
+
+// SymbolPolicy 符号访问策略，不为nil时沙箱化脚本，作用于所有导入的符号表与脚本代码
+func (_Option) SymbolPolicy(policy *dynamic.SymbolPolicy) option.Setting[ScriptOptions] {
+	return func(options *ScriptOptions) {
+		options.SymbolPolicy = policy
+	}
+}
+
+// CallBudget 脚本方法单次调用时间预算，超出时视为调用失败，为0表示不限制
+func (_Option) CallBudget(d time.Duration) option.Setting[ScriptOptions] {
+	return func(options *ScriptOptions) {
+		if d < 0 {
+			exception.Panicf("goscr: %w: option CallBudget can't be set to a value less than 0", core.ErrArgs)
+		}
+		options.CallBudget = d
+	}
+}
+
+// MaxCallFailures 脚本方法连续调用失败次数阈值，达到后禁用组件或停止调用实体脚本方法，为0表示不禁用
+func (_Option) MaxCallFailures(n int) option.Setting[ScriptOptions] {
+	return func(options *ScriptOptions) {
+		if n < 0 {
+			exception.Panicf("goscr: %w: option MaxCallFailures can't be set to a value less than 0", core.ErrArgs)
+		}
+		options.MaxCallFailures = n
+	}
+}
+
+// IncrementalCompile 热更新时增量编译，只重新编译源码变化的包与通过导入关系与它们相连的包，
+// 复用的包仍引用旧解决方案的解释器，旧解决方案排空后也无法完全释放，使用远程清单的项目不受此选项影响，总是增量编译
+func (_Option) IncrementalCompile(b bool) option.Setting[ScriptOptions] {
+	return func(options *ScriptOptions) {
+		options.IncrementalCompile = b
+	}
+}
+
+// ClusterHotfix 集群协同热更新，所有副本加载并校验通过后才同时替换解决方案
+func (_Option) ClusterHotfix(b bool) option.Setting[ScriptOptions] {
+	return func(options *ScriptOptions) {
+		options.ClusterHotfix = b
+	}
+}
+
+// ClusterHotfixTimeout 集群协同热更新等待副本就绪的超时时间
+func (_Option) ClusterHotfixTimeout(d time.Duration) option.Setting[ScriptOptions] {
+	return func(options *ScriptOptions) {
+		if d <= 0 {
+			exception.Panicf("goscr: %w: option ClusterHotfixTimeout can't be set to a value less equal 0", core.ErrArgs)
+		}
+		options.ClusterHotfixTimeout = d
+	}
+}
+
+// SourceTimeout 加载项目或检测源码变化时，获取源码版本与源码的超时时间
+func (_Option) SourceTimeout(d time.Duration) option.Setting[ScriptOptions] {
+	return func(options *ScriptOptions) {
+		if d <= 0 {
+			exception.Panicf("goscr: %w: option SourceTimeout can't be set to a value less equal 0", core.ErrArgs)
+		}
+		options.SourceTimeout = d
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package goscr
+
+import (
+	"sync/atomic"
+
+	"git.golaxy.org/scaffold/addins/goscr/dynamic"
+)
+
+// PinnedSolution 固定版本的解决方案，解除固定前旧版本的解决方案不会被释放
+type PinnedSolution struct {
+	gen *_SolutionGen
+}
+
+// Solution 解决方案
+func (p PinnedSolution) Solution() *dynamic.Solution {
+	if p.gen == nil {
+		return nil
+	}
+	return p.gen.solution
+}
+
+// Generation 解决方案版本号
+func (p PinnedSolution) Generation() int64 {
+	if p.gen == nil {
+		return 0
+	}
+	return p.gen.generation
+}
+
+// Unpin 解除固定
+func (p PinnedSolution) Unpin() {
+	if p.gen == nil {
+		return
+	}
+	p.gen.unpin()
+}
+
+func newSolutionGen(solution *dynamic.Solution, generation int64, drained func(gen *_SolutionGen)) *_SolutionGen {
+	return &_SolutionGen{
+		solution:   solution,
+		generation: generation,
+		drained:    drained,
+	}
+}
+
+// _SolutionGen 解决方案版本，记录正在执行的调用数量，被替换后所有调用结束时才能释放
+type _SolutionGen struct {
+	solution   *dynamic.Solution
+	generation int64
+	pinned     atomic.Int64
+	retired    atomic.Bool
+	released   atomic.Bool
+	freed      atomic.Bool
+	drained    func(gen *_SolutionGen)
+}
+
+func (gen *_SolutionGen) pin() bool {
+	gen.pinned.Add(1)
+	if gen.retired.Load() {
+		gen.unpin()
+		return false
+	}
+	return true
+}
+
+func (gen *_SolutionGen) unpin() {
+	if gen.pinned.Add(-1) == 0 && gen.retired.Load() {
+		gen.release()
+	}
+}
+
+func (gen *_SolutionGen) retire() {
+	gen.retired.Store(true)
+	if gen.pinned.Load() == 0 {
+		gen.release()
+	}
+}
+
+func (gen *_SolutionGen) release() {
+	if !gen.released.CompareAndSwap(false, true) {
+		return
+	}
+	if gen.drained != nil {
+		gen.drained(gen)
+	}
+}
+
+// free 释放解决方案的解释器与已编译的脚本，只会执行一次
+func (gen *_SolutionGen) free() bool {
+	if !gen.freed.CompareAndSwap(false, true) {
+		return false
+	}
+	gen.solution.Release()
+	return true
+}
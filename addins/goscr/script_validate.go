@@ -0,0 +1,176 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package goscr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"git.golaxy.org/core/utils/meta"
+	"git.golaxy.org/scaffold/addins/goscr/dynamic"
+)
+
+var (
+	// EntityLifecycleMethods 实体脚本生命周期方法
+	EntityLifecycleMethods = []string{"Awake", "Start", "Update", "LateUpdate", "Shut", "Dispose"}
+	// ComponentLifecycleMethods 组件脚本生命周期方法
+	ComponentLifecycleMethods = []string{"Awake", "OnEnable", "Start", "Update", "LateUpdate", "Shut", "OnDisable", "Dispose"}
+)
+
+// validateSolution 校验解决方案，检查所有实体原型引用的脚本类型与生命周期方法
+func (s *_Script) validateSolution(solution *dynamic.Solution) error {
+	var errs []error
+
+	for _, entityPT := range s.svcCtx.EntityLib().List() {
+		if err := validateScript(solution, entityPT.Meta(), entityPT.InstanceRT(), EntityLifecycleMethods); err != nil {
+			errs = append(errs, fmt.Errorf("entity %q, %s", entityPT.Prototype(), err))
+		}
+
+		for _, comp := range entityPT.ListComponents() {
+			if err := validateScript(solution, comp.Meta, comp.PT.InstanceRT(), ComponentLifecycleMethods); err != nil {
+				errs = append(errs, fmt.Errorf("entity %q component %q, %s", entityPT.Prototype(), comp.Name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateScript(solution *dynamic.Solution, m meta.Meta, instanceRT reflect.Type, lifecycleMethods []string) error {
+	scriptPkg, ok := m.Get("script_pkg")
+	if !ok {
+		return nil
+	}
+
+	scriptIdent, ok := m.Get("script_ident")
+	if !ok {
+		return nil
+	}
+
+	script := solution.Package(scriptPkg.(string)).Ident(scriptIdent.(string))
+	if script == nil {
+		return fmt.Errorf("script %s.%s not found", scriptPkg, scriptIdent)
+	}
+
+	if script.BindMode == dynamic.None || script.MethodBinder == nil {
+		return fmt.Errorf("script %s.%s can't be bound", scriptPkg, scriptIdent)
+	}
+
+	if err := validateThis(script, instanceRT); err != nil {
+		return fmt.Errorf("script %s.%s, %s", scriptPkg, scriptIdent, err)
+	}
+
+	for _, method := range script.Methods {
+		if !slices.Contains(lifecycleMethods, method.Name) {
+			continue
+		}
+
+		if !method.Reflected.IsValid() || method.Reflected.Kind() != reflect.Func {
+			return fmt.Errorf("script %s.%s lifecycle method %q not compiled", scriptPkg, scriptIdent, method.Name)
+		}
+
+		// 方法表达式的第一个参数为接收者
+		methodRT := method.Reflected.Type()
+		if methodRT.NumIn() != 1 || methodRT.NumOut() != 0 {
+			return fmt.Errorf("script %s.%s lifecycle method %q has incorrect signature %s", scriptPkg, scriptIdent, method.Name, methodRT)
+		}
+	}
+
+	for _, method := range script.Methods {
+		var ok bool
+
+		switch method.Name {
+		case "OnBeforeHotfix":
+			ok = isMethodRT(method.Reflected, nil, []reflect.Type{anyRT})
+		case "OnAfterHotfix":
+			ok = isMethodRT(method.Reflected, []reflect.Type{anyRT}, nil)
+		default:
+			continue
+		}
+
+		if !ok {
+			return fmt.Errorf("script %s.%s hotfix method %q has incorrect signature", scriptPkg, scriptIdent, method.Name)
+		}
+	}
+
+	return nil
+}
+
+var anyRT = reflect.TypeFor[any]()
+
+// isMethodRT 检查方法表达式的参数与返回值类型，第一个参数为接收者
+func isMethodRT(methodRV reflect.Value, in, out []reflect.Type) bool {
+	if !methodRV.IsValid() || methodRV.Kind() != reflect.Func {
+		return false
+	}
+
+	methodRT := methodRV.Type()
+	if methodRT.NumIn() != len(in)+1 || methodRT.NumOut() != len(out) {
+		return false
+	}
+
+	for i := range in {
+		if methodRT.In(i+1) != in[i] {
+			return false
+		}
+	}
+
+	for i := range out {
+		if methodRT.Out(i) != out[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validateThis(script *dynamic.Script, instanceRT reflect.Type) error {
+	if script.This == nil || instanceRT == nil {
+		return nil
+	}
+
+	if instanceRT.Kind() != reflect.Pointer {
+		instanceRT = reflect.PointerTo(instanceRT)
+	}
+
+	thisRT := instanceRT
+
+	if script.BindMode == dynamic.Func {
+		method, ok := instanceRT.MethodByName("This")
+		if !ok {
+			return fmt.Errorf("instance %s has no This method", instanceRT)
+		}
+
+		methodRT := method.Type
+		if methodRT.NumOut() != 1 || methodRT.Out(0).Kind() != reflect.Func || methodRT.Out(0).NumOut() != 1 {
+			return fmt.Errorf("instance %s has incorrect This method %s", instanceRT, methodRT)
+		}
+
+		thisRT = methodRT.Out(0).Out(0)
+	}
+
+	if thisRT.Kind() != reflect.Pointer || thisRT.Elem().PkgPath() != script.This.PkgPath || thisRT.Elem().Name() != script.This.Name {
+		return fmt.Errorf("this type %s mismatch, expected *%s.%s", thisRT, script.This.PkgPath, script.This.Name)
+	}
+
+	return nil
+}
@@ -39,6 +39,16 @@ func DeclareProp(entity ec.Entity, name string, prop any, syncTo ...string) IPro
 	return declareProp(entity, name, prop, syncTo)
 }
 
+// DeclarePersistentPropT 定义持久化属性，异步从持久化存储恢复数据，恢复完成前未就绪，并按刷新策略写回持久化存储
+func DeclarePersistentPropT[T IPropSync](entity ec.Entity, name string, syncTo ...string) T {
+	return declarePersistentProp(entity, name, reflect.TypeFor[T](), syncTo).(T)
+}
+
+// DeclarePersistentProp 定义持久化属性，异步从持久化存储恢复数据，恢复完成前未就绪，并按刷新策略写回持久化存储
+func DeclarePersistentProp(entity ec.Entity, name string, prop any, syncTo ...string) IPropSync {
+	return declarePersistentProp(entity, name, prop, syncTo)
+}
+
 // ReferencePropT 引用属性
 func ReferencePropT[T IPropSync](entity ec.Entity, name string) T {
 	return referenceProp(entity, name).(T)
@@ -77,14 +87,36 @@ func declareProp(entity ec.Entity, name string, prop any, syncTo []string) IProp
 		exception.Panicf("propview: prop %q not implement propview.IPropSync", types.FullNameRT(propRT))
 	}
 
-	propInst.init(AddIn.Require(runtime.Current(entity)), entity, name, reflect.ValueOf(propInst.Managed()), syncTo)
+	propInst.init(propInst, AddIn.Require(runtime.Current(entity)), entity, name, reflect.ValueOf(propInst.Managed()), syncTo)
 	propInst.Managed().Reset()
 
+	if provider, ok := propInst.(ISyncPolicyProvider); ok {
+		if propSyncEx, ok := propInst.(IPropSyncEx); ok {
+			propSyncEx.SetSyncPolicy(provider.SyncPolicy())
+		}
+	}
+
 	propTab.AddProp(name, propInst)
 
 	return propInst
 }
 
+func declarePersistentProp(entity ec.Entity, name string, prop any, syncTo []string) IPropSync {
+	propInst := declareProp(entity, name, prop, syncTo)
+
+	propSyncEx, ok := propInst.(IPropSyncEx)
+	if !ok {
+		exception.Panicf("propview: prop %q not implement propview.IPropSyncEx", types.FullNameRT(reflect.TypeOf(propInst)))
+	}
+
+	// 异步恢复，失败时记录日志，属性保持未就绪，不会覆盖持久化存储中的数据
+	propSyncEx.Restore(nil)
+
+	AddIn.Require(runtime.Current(entity)).Persist(propSyncEx)
+
+	return propInst
+}
+
 func referenceProp(entity ec.Entity, name string) IPropSync {
 	if entity == nil {
 		exception.Panicf("propview: %s: entity is nil", core.ErrArgs)
@@ -80,23 +80,37 @@ func (p *PropT[T]) Marshal() ([]byte, int64, error) {
 		return nil, 0, err
 	}
 
+	if schema := getPropSchema(reflect.TypeFor[T]()); schema != nil {
+		bs, err = encodePropSchemaVersion(schema.version, bs)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
 	return bs, p.revision, nil
 }
 
 // Unmarshal 反序列化
 func (p *PropT[T]) Unmarshal(data []byte, revision int64) error {
-	v := variant.Variant{}
-
-	if _, err := v.Write(data); err != nil {
+	version, state, err := decodePropSchemaVersion(data)
+	if err != nil {
 		return err
 	}
 
-	value, ok := v.Value.(T)
+	if schema := getPropSchema(reflect.TypeFor[T]()); schema != nil || version > 0 {
+		state, err = migratePropState(schema, version, state)
+		if err != nil {
+			return err
+		}
+	}
+
+	value, ok := state.(T)
 	if !ok {
 		return errors.New("incorrect state type")
 	}
 
 	p.state = value
+	p.reflectedState = reflect.ValueOf(value)
 	p.revision = revision
 
 	return nil
@@ -0,0 +1,212 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package propview
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+	"sync"
+
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/framework/net/gap/variant"
+	"git.golaxy.org/framework/utils/binaryutil"
+)
+
+// PropMigration 属性结构迁移函数，将fromVersion版本的状态值升级为fromVersion+1版本
+type PropMigration = func(state variant.Value) (variant.Value, error)
+
+// RegisterPropMigration 注册属性结构迁移，状态类型T的结构版本号为已注册的最大fromVersion+1，
+// 序列化数据中会记录结构版本号，反序列化时按版本号依次迁移旧数据，迁移过程中的旧状态类型需要保持注册在variant中
+func RegisterPropMigration[T variant.Value](fromVersion int, fn PropMigration) {
+	if fromVersion < 0 {
+		exception.Panicf("propview: %w: fromVersion less than 0", exception.ErrArgs)
+	}
+
+	if fn == nil {
+		exception.Panicf("propview: %w: fn is nil", exception.ErrArgs)
+	}
+
+	stateRT := reflect.TypeFor[T]()
+
+	propSchemasMutex.Lock()
+	defer propSchemasMutex.Unlock()
+
+	schema, ok := propSchemas[stateRT]
+	if !ok {
+		schema = &_PropSchema{migrations: map[int]PropMigration{}}
+		propSchemas[stateRT] = schema
+	}
+
+	if _, ok := schema.migrations[fromVersion]; ok {
+		exception.Panicf("propview: migration of %s from version %d already registered", stateRT, fromVersion)
+	}
+
+	schema.migrations[fromVersion] = fn
+	schema.version = max(schema.version, fromVersion+1)
+}
+
+type _PropSchema struct {
+	version    int
+	migrations map[int]PropMigration
+}
+
+var (
+	propSchemas      = map[reflect.Type]*_PropSchema{}
+	propSchemasMutex sync.RWMutex
+)
+
+func getPropSchema(stateRT reflect.Type) *_PropSchema {
+	propSchemasMutex.RLock()
+	defer propSchemasMutex.RUnlock()
+	return propSchemas[stateRT]
+}
+
+func init() {
+	variant.VariantCreator().Declare(&_PropSchemaEnvelope{})
+}
+
+// _PropSchemaEnvelope 结构版本信封，使用独立注册的variant类型id包装带结构版本号的序列化数据，
+// 类型id由variant统一注册，不会与其他类型冲突，旧数据不带信封，读取时视为版本0
+type _PropSchemaEnvelope struct {
+	Version uint64 // 结构版本号
+	Data    []byte // 状态值序列化数据
+}
+
+// Read implements io.Reader
+func (e *_PropSchemaEnvelope) Read(p []byte) (int, error) {
+	if len(p) < e.Size() {
+		return 0, io.ErrShortBuffer
+	}
+	n := binary.PutUvarint(p, e.Version)
+	n += binary.PutUvarint(p[n:], uint64(len(e.Data)))
+	n += copy(p[n:], e.Data)
+	return n, io.EOF
+}
+
+// Write implements io.Writer
+func (e *_PropSchemaEnvelope) Write(p []byte) (int, error) {
+	version, n := binary.Uvarint(p)
+	if n <= 0 {
+		return 0, errors.New("incorrect schema version")
+	}
+	size, m := binary.Uvarint(p[n:])
+	if m <= 0 || uint64(len(p[n+m:])) < size {
+		return 0, errors.New("incorrect schema data")
+	}
+	n += m
+	e.Version = version
+	e.Data = append([]byte(nil), p[n:n+int(size)]...)
+	return n + int(size), nil
+}
+
+// Size returns the serialized size
+func (e *_PropSchemaEnvelope) Size() int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], e.Version) + binary.PutUvarint(buf[:], uint64(len(e.Data))) + len(e.Data)
+}
+
+// TypeID returns the variant type id
+func (e *_PropSchemaEnvelope) TypeID() variant.TypeID {
+	return propSchemaEnvelopeTypeID
+}
+
+// Indirect returns the original value
+func (e *_PropSchemaEnvelope) Indirect() any {
+	return e
+}
+
+var propSchemaEnvelopeTypeID = func() variant.TypeID {
+	hash := fnv.New32a()
+	hash.Write([]byte("propview.PropSchemaEnvelope"))
+	return variant.TypeID(variant.TypeID_Customize + hash.Sum32())
+}()
+
+// encodePropSchemaVersion 使用结构版本信封包装序列化数据，版本号为0时保持旧格式
+func encodePropSchemaVersion(version int, data []byte) ([]byte, error) {
+	if version <= 0 {
+		return data, nil
+	}
+
+	v, err := variant.NewVariant(&_PropSchemaEnvelope{Version: uint64(version), Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	bs := make([]byte, v.Size())
+
+	if _, err := binaryutil.CopyToBuff(bs, v); err != nil {
+		return nil, err
+	}
+
+	return bs, nil
+}
+
+// decodePropSchemaVersion 读取序列化数据，解开结构版本信封，旧格式数据版本号为0
+func decodePropSchemaVersion(data []byte) (int, variant.Value, error) {
+	v := variant.Variant{}
+
+	if _, err := v.Write(data); err != nil {
+		return 0, nil, err
+	}
+
+	envelope, ok := v.Value.(*_PropSchemaEnvelope)
+	if !ok {
+		return 0, v.Value, nil
+	}
+
+	v = variant.Variant{}
+
+	if _, err := v.Write(envelope.Data); err != nil {
+		return 0, nil, err
+	}
+
+	return int(envelope.Version), v.Value, nil
+}
+
+// migratePropState 将状态值从version版本迁移至当前版本
+func migratePropState(schema *_PropSchema, version int, state variant.Value) (variant.Value, error) {
+	current := 0
+	if schema != nil {
+		current = schema.version
+	}
+
+	if version > current {
+		return nil, fmt.Errorf("schema version %d is newer than %d", version, current)
+	}
+
+	for ; version < current; version++ {
+		fn, ok := schema.migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("migration from schema version %d not found", version)
+		}
+
+		var err error
+		state, err = fn(state)
+		if err != nil {
+			return nil, fmt.Errorf("migrate from schema version %d failed, %w", version, err)
+		}
+	}
+
+	return state, nil
+}
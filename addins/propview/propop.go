@@ -0,0 +1,201 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package propview
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"git.golaxy.org/framework/net/gap/variant"
+)
+
+// IPropOpApplier 属性操作分发器，由propc生成，存在时优先于反射调用
+type IPropOpApplier interface {
+	// ApplyOp 应用操作
+	ApplyOp(op string, args []reflect.Value) error
+}
+
+// OpArgError 操作参数错误
+type OpArgError struct {
+	Op    string       // 操作名
+	Index int          // 参数位置
+	Param string       // 参数名
+	Type  reflect.Type // 参数类型
+	Err   error        // ErrMethodParameterTypeMismatch
+	Cause error        // 原因
+}
+
+// Error 错误信息
+func (e *OpArgError) Error() string {
+	var msg string
+	if e.Param != "" {
+		msg = fmt.Sprintf("op %q parameter %d %q (%s): %s", e.Op, e.Index, e.Param, e.Type, e.Err)
+	} else {
+		msg = fmt.Sprintf("op %q parameter %d (%s): %s", e.Op, e.Index, e.Type, e.Err)
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+// Unwrap 解包错误
+func (e *OpArgError) Unwrap() error {
+	return e.Err
+}
+
+// ConvertOpArg 转换操作参数，不会panic
+func ConvertOpArg[T any](op string, idx int, param string, arg reflect.Value) (T, error) {
+	paramRT := reflect.TypeFor[T]()
+
+	argErr := func(cause error) (T, error) {
+		var zero T
+		return zero, &OpArgError{
+			Op:    op,
+			Index: idx,
+			Param: param,
+			Type:  paramRT,
+			Err:   ErrMethodParameterTypeMismatch,
+			Cause: cause,
+		}
+	}
+
+	argRV, err := convertOpArg(arg, paramRT)
+	if err != nil {
+		return argErr(err)
+	}
+
+	v, ok := argRV.Interface().(T)
+	if !ok {
+		return argErr(fmt.Errorf("got %s", argRV.Type()))
+	}
+
+	return v, nil
+}
+
+// callOp 使用反射调用操作，未生成ApplyOp时使用
+func callOp(managedRV reflect.Value, op string, argsRV []reflect.Value) error {
+	methodRV := managedRV.MethodByName(op)
+	if !methodRV.IsValid() {
+		return ErrMethodNotFound
+	}
+	methodRT := methodRV.Type()
+
+	if methodRT.NumIn() != len(argsRV) {
+		return ErrMethodParameterCountMismatch
+	}
+
+	for i := range argsRV {
+		paramRT := methodRT.In(i)
+
+		argRV, err := convertOpArg(argsRV[i], paramRT)
+		if err != nil {
+			return &OpArgError{
+				Op:    op,
+				Index: i,
+				Type:  paramRT,
+				Err:   ErrMethodParameterTypeMismatch,
+				Cause: err,
+			}
+		}
+
+		argsRV[i] = argRV
+	}
+
+	if methodRT.IsVariadic() {
+		methodRV.CallSlice(argsRV)
+	} else {
+		methodRV.Call(argsRV)
+	}
+
+	return nil
+}
+
+func convertOpArg(argRV reflect.Value, paramRT reflect.Type) (reflect.Value, error) {
+	if !argRV.IsValid() {
+		switch paramRT.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+			return reflect.Zero(paramRT), nil
+		default:
+			return reflect.Value{}, errors.New("got nil")
+		}
+	}
+
+	if !argRV.CanInterface() {
+		return reflect.Value{}, errors.New("inaccessible value")
+	}
+
+	origRV := argRV
+
+	for {
+		argRT := argRV.Type()
+
+		if argRT.AssignableTo(paramRT) {
+			return argRV, nil
+		}
+
+		if argRV.CanConvert(paramRT) {
+			if argRT.Size() > paramRT.Size() {
+				return reflect.Value{}, fmt.Errorf("narrowing %s", argRT)
+			}
+			return argRV.Convert(paramRT), nil
+		}
+
+		if argRT.Kind() != reflect.Pointer {
+			break
+		}
+
+		if argRV.IsNil() {
+			return reflect.Value{}, fmt.Errorf("got nil %s", argRT)
+		}
+		argRV = argRV.Elem()
+	}
+
+	v, ok := origRV.Interface().(variant.Variant)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("got %s", origRV.Type())
+	}
+
+	return v.ToNative(paramRT)
+}
+
+// opArgs 转换操作参数，用于属性变化事件
+func opArgs(argsRV []reflect.Value) []any {
+	args := make([]any, len(argsRV))
+	for i, argRV := range argsRV {
+		if argRV.IsValid() && argRV.CanInterface() {
+			args[i] = argRV.Interface()
+		}
+	}
+	return args
+}
+
+// opErrCode 转换为可返回给调用方的错误码
+func opErrCode(err error) error {
+	switch {
+	case errors.Is(err, ErrMethodNotFound):
+		return ErrMethodNotFound
+	case errors.Is(err, ErrMethodParameterCountMismatch):
+		return ErrMethodParameterCountMismatch
+	default:
+		return ErrMethodParameterTypeMismatch
+	}
+}
@@ -0,0 +1,48 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package propview
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"git.golaxy.org/core/utils/uid"
+)
+
+var (
+	ErrPropDataNotFound = errors.New("propview: prop data not found")
+	ErrStoreNotSet      = errors.New("propview: store not set")
+)
+
+// IPropStore 属性持久化存储接口
+type IPropStore interface {
+	// Load 加载属性数据，数据不存在时返回ErrPropDataNotFound
+	Load(ctx context.Context, entityID uid.ID, prop string) ([]byte, int64, error)
+	// Save 保存属性数据
+	Save(ctx context.Context, entityID uid.ID, prop string, data []byte, revision int64) error
+}
+
+// FlushPolicy 持久化刷新策略
+type FlushPolicy struct {
+	Revisions int64         // 累计变化的版本号数量达到阈值时刷新，<=0表示不启用
+	Interval  time.Duration // 定时刷新间隔，<=0表示不启用
+	OnShut    bool          // 实体销毁时刷新
+}
@@ -0,0 +1,83 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package propview
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"git.golaxy.org/core/utils/uid"
+)
+
+// NewMemPropStore 创建内存属性存储，一般用于测试
+func NewMemPropStore() *MemPropStore {
+	return &MemPropStore{
+		records: map[_MemPropKey]_MemPropRecord{},
+	}
+}
+
+type _MemPropKey struct {
+	entityID uid.ID
+	prop     string
+}
+
+type _MemPropRecord struct {
+	data     []byte
+	revision int64
+}
+
+// MemPropStore 内存属性存储
+type MemPropStore struct {
+	mutex   sync.RWMutex
+	records map[_MemPropKey]_MemPropRecord
+}
+
+// Load 加载属性数据
+func (s *MemPropStore) Load(ctx context.Context, entityID uid.ID, prop string) ([]byte, int64, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	record, ok := s.records[_MemPropKey{entityID: entityID, prop: prop}]
+	if !ok {
+		return nil, 0, ErrPropDataNotFound
+	}
+
+	return slices.Clone(record.data), record.revision, nil
+}
+
+// Save 保存属性数据
+func (s *MemPropStore) Save(ctx context.Context, entityID uid.ID, prop string, data []byte, revision int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := _MemPropKey{entityID: entityID, prop: prop}
+
+	if record, ok := s.records[key]; ok && record.revision > revision {
+		return ErrOutdatedRevision
+	}
+
+	s.records[key] = _MemPropRecord{
+		data:     slices.Clone(data),
+		revision: revision,
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package propview
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/uid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// NewMongoPropStore 创建MongoDB属性存储
+func NewMongoPropStore(coll *mongo.Collection) *MongoPropStore {
+	if coll == nil {
+		exception.Panicf("propview: %w: coll is nil", core.ErrArgs)
+	}
+	return &MongoPropStore{
+		coll: coll,
+	}
+}
+
+// MongoPropStore MongoDB属性存储
+type MongoPropStore struct {
+	coll *mongo.Collection
+}
+
+type _MongoPropRecord struct {
+	EntityID  string    `bson:"entity_id"`
+	Prop      string    `bson:"prop"`
+	Data      []byte    `bson:"data"`
+	Revision  int64     `bson:"revision"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// MigrateDB 创建索引
+func (s *MongoPropStore) MigrateDB() error {
+	_, err := s.coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "entity_id", Value: 1}, {Key: "prop", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// Load 加载属性数据
+func (s *MongoPropStore) Load(ctx context.Context, entityID uid.ID, prop string) ([]byte, int64, error) {
+	var record _MongoPropRecord
+
+	err := s.coll.FindOne(ctx, bson.D{{Key: "entity_id", Value: entityID.String()}, {Key: "prop", Value: prop}}).Decode(&record)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, 0, ErrPropDataNotFound
+		}
+		return nil, 0, err
+	}
+
+	return record.Data, record.Revision, nil
+}
+
+// Save 保存属性数据
+func (s *MongoPropStore) Save(ctx context.Context, entityID uid.ID, prop string, data []byte, revision int64) error {
+	filter := bson.D{
+		{Key: "entity_id", Value: entityID.String()},
+		{Key: "prop", Value: prop},
+		{Key: "revision", Value: bson.D{{Key: "$lte", Value: revision}}},
+	}
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "data", Value: data},
+			{Key: "revision", Value: revision},
+			{Key: "updated_at", Value: time.Now()},
+		}},
+	}
+
+	_, err := s.coll.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true))
+	if err != nil {
+		// 版本号过期时，过滤条件无法匹配，upsert会与唯一索引冲突
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrOutdatedRevision
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package propview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/uid"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedisPropStore 创建Redis属性存储，每个实体使用一个Hash保存所有属性
+func NewRedisPropStore(cli redis.UniversalClient, keyPrefix string) *RedisPropStore {
+	if cli == nil {
+		exception.Panicf("propview: %w: cli is nil", core.ErrArgs)
+	}
+	return &RedisPropStore{
+		cli:       cli,
+		keyPrefix: keyPrefix,
+	}
+}
+
+// RedisPropStore Redis属性存储
+type RedisPropStore struct {
+	cli       redis.UniversalClient
+	keyPrefix string
+}
+
+var redisSaveScript = redis.NewScript(`
+local rev = redis.call('HGET', KEYS[1], ARGV[1])
+if rev and tonumber(rev) > tonumber(ARGV[4]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[2], ARGV[3], ARGV[1], ARGV[4])
+return 1
+`)
+
+// Load 加载属性数据
+func (s *RedisPropStore) Load(ctx context.Context, entityID uid.ID, prop string) ([]byte, int64, error) {
+	vals, err := s.cli.HMGet(ctx, s.key(entityID), s.revisionField(prop), s.dataField(prop)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(vals) != 2 || vals[0] == nil || vals[1] == nil {
+		return nil, 0, ErrPropDataNotFound
+	}
+
+	revStr, ok := vals[0].(string)
+	if !ok {
+		return nil, 0, errors.New("propview: incorrect revision type")
+	}
+
+	revision, err := strconv.ParseInt(revStr, 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("propview: parse revision failed, %s", err)
+	}
+
+	data, ok := vals[1].(string)
+	if !ok {
+		return nil, 0, errors.New("propview: incorrect data type")
+	}
+
+	return []byte(data), revision, nil
+}
+
+// Save 保存属性数据
+func (s *RedisPropStore) Save(ctx context.Context, entityID uid.ID, prop string, data []byte, revision int64) error {
+	ret, err := redisSaveScript.Run(ctx, s.cli, []string{s.key(entityID)}, s.revisionField(prop), s.dataField(prop), data, revision).Int()
+	if err != nil {
+		return err
+	}
+	if ret == 0 {
+		return ErrOutdatedRevision
+	}
+	return nil
+}
+
+func (s *RedisPropStore) key(entityID uid.ID) string {
+	return s.keyPrefix + entityID.String()
+}
+
+func (s *RedisPropStore) revisionField(prop string) string {
+	return prop + ":revision"
+}
+
+func (s *RedisPropStore) dataField(prop string) string {
+	return prop + ":data"
+}
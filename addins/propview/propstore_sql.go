@@ -0,0 +1,116 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package propview
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/uid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PropRecord SQL属性存储记录
+type PropRecord struct {
+	EntityID  string    `gorm:"primaryKey;size:64"`  // 实体ID
+	Prop      string    `gorm:"primaryKey;size:128"` // 属性名
+	Data      []byte    // 属性数据
+	Revision  int64     // 版本号
+	UpdatedAt time.Time // 更新时间
+}
+
+// NewSQLPropStore 创建SQL属性存储，table为空时使用默认表名
+func NewSQLPropStore(db *gorm.DB, table string) *SQLPropStore {
+	if db == nil {
+		exception.Panicf("propview: %w: db is nil", core.ErrArgs)
+	}
+	return &SQLPropStore{
+		db:    db,
+		table: table,
+	}
+}
+
+// SQLPropStore SQL属性存储
+type SQLPropStore struct {
+	db    *gorm.DB
+	table string
+}
+
+// MigrateDB 迁移表结构
+func (s *SQLPropStore) MigrateDB() error {
+	return s.session(context.Background()).AutoMigrate(&PropRecord{})
+}
+
+// Load 加载属性数据
+func (s *SQLPropStore) Load(ctx context.Context, entityID uid.ID, prop string) ([]byte, int64, error) {
+	var record PropRecord
+
+	err := s.session(ctx).
+		Where("entity_id = ? AND prop = ?", entityID.String(), prop).
+		Take(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrPropDataNotFound
+		}
+		return nil, 0, err
+	}
+
+	return record.Data, record.Revision, nil
+}
+
+// Save 保存属性数据
+func (s *SQLPropStore) Save(ctx context.Context, entityID uid.ID, prop string, data []byte, revision int64) error {
+	return s.session(ctx).Transaction(func(tx *gorm.DB) error {
+		var record PropRecord
+
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("entity_id = ? AND prop = ?", entityID.String(), prop).
+			Take(&record).Error
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		} else if record.Revision > revision {
+			return ErrOutdatedRevision
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "entity_id"}, {Name: "prop"}},
+			DoUpdates: clause.AssignmentColumns([]string{"data", "revision", "updated_at"}),
+		}).Create(&PropRecord{
+			EntityID: entityID.String(),
+			Prop:     prop,
+			Data:     data,
+			Revision: revision,
+		}).Error
+	})
+}
+
+func (s *SQLPropStore) session(ctx context.Context) *gorm.DB {
+	db := s.db.WithContext(ctx)
+	if s.table != "" {
+		db = db.Table(s.table)
+	}
+	return db
+}
@@ -20,10 +20,16 @@
 package propview
 
 import (
+	"errors"
 	"reflect"
 
 	"git.golaxy.org/core/ec"
+	"git.golaxy.org/core/event"
+	"git.golaxy.org/core/runtime"
 	"git.golaxy.org/core/utils/meta"
+	"git.golaxy.org/framework/addins/gate"
+	"git.golaxy.org/framework/addins/log"
+	"go.uber.org/zap"
 )
 
 // IPropSync 属性同步接口
@@ -41,11 +47,36 @@ type IPropSync interface {
 	Meta() *meta.Meta
 }
 
+// IPropSyncEx 属性同步扩展接口，PropSyncer已实现，使用时通过类型断言获取
+type IPropSyncEx interface {
+	IPropSync
+	// Restore 异步从持久化存储恢复，恢复完成前未就绪，完成后在运行时中回调，cb可以为nil
+	Restore(cb func(err error))
+	// Ready 是否就绪，从持久化存储恢复完成前未就绪
+	Ready() bool
+	// Flush 刷新至持久化存储
+	Flush() error
+	// Snapshot 以OpSnapshot同步全量数据至所有客户端目标，用于客户端镜像初始化或版本号断档后重新同步
+	Snapshot()
+	// Entity 所属实体
+	Entity() ec.Entity
+	// Name 属性名
+	Name() string
+	// SetSyncPolicy 设置同步策略
+	SetSyncPolicy(policy *SyncPolicy)
+	// EventPropChanged 事件：属性变化
+	EventPropChanged() event.IEvent
+}
+
 type iPropSyncer interface {
-	init(view IPropView, entity ec.Entity, name string, reflectedManaged reflect.Value, syncTo []string)
+	init(self IPropSync, view IPropView, entity ec.Entity, name string, reflectedManaged reflect.Value, syncTo []string)
 	load(service string) ([]byte, int64, error)
 	save(service string, data []byte, revision int64) error
 	sync(revision int64, op string, args ...any)
+	persist(policy FlushPolicy)
+	replay(dst string, from int64) ([]any, bool)
+	changed(op string, args []any, oldRevision, newRevision int64)
+	snapshot(filter func(dst string) bool)
 }
 
 // PropSyncer 属性同步器
@@ -56,14 +87,27 @@ type PropSyncer struct {
 	reflectedManaged reflect.Value
 	syncTo           []string
 	meta             meta.Meta
+	flushPolicy      *FlushPolicy
+	flushedRevision  int64
+	flushingRevision int64
+	history          _OpHistory
+	syncPolicy       *SyncPolicy
+	pending          bool
+	self             IPropSync
+	eventPropChanged event.Event
 }
 
-func (ps *PropSyncer) init(view IPropView, entity ec.Entity, name string, reflectedManaged reflect.Value, syncTo []string) {
+func (ps *PropSyncer) init(self IPropSync, view IPropView, entity ec.Entity, name string, reflectedManaged reflect.Value, syncTo []string) {
+	ps.self = self
 	ps.view = view
 	ps.entity = entity
 	ps.name = name
 	ps.reflectedManaged = reflectedManaged
 	ps.syncTo = syncTo
+	ps.history.init(DefaultSyncHistorySize)
+
+	rtCtx := runtime.Current(entity)
+	ps.eventPropChanged.SetPanicHandling(rtCtx.AutoRecover(), rtCtx.ReportError())
 }
 
 func (ps *PropSyncer) load(service string) ([]byte, int64, error) {
@@ -75,7 +119,171 @@ func (ps *PropSyncer) save(service string, data []byte, revision int64) error {
 }
 
 func (ps *PropSyncer) sync(revision int64, op string, args ...any) {
-	ps.view.Sync(ps.entity.ID(), ps.name, ps.syncTo, revision, op, args...)
+	ps.history.push(revision, op, args)
+	ps.view.SyncWithPolicy(ps.entity.ID(), ps.name, ps.syncTo, ps.syncPolicy, revision, op, args...)
+	ps.changed(op, args, revision-1, revision)
+
+	if ps.flushPolicy != nil && ps.flushPolicy.Revisions > 0 && revision-max(ps.flushedRevision, ps.flushingRevision) >= ps.flushPolicy.Revisions {
+		if err := ps.Flush(); err != nil {
+			log.L(runtime.Current(ps.entity)).Error("flush prop data failed",
+				zap.String("entity_id", ps.entity.ID().String()),
+				zap.String("prop", ps.name),
+				zap.Int64("revision", revision),
+				zap.Error(err))
+		}
+	}
+}
+
+func (ps *PropSyncer) replay(dst string, from int64) ([]any, bool) {
+	ops, ok := ps.history.replay(ps.name, from, ps.managed().Revision())
+	if !ok {
+		return nil, false
+	}
+	return ps.syncPolicy.applyBatch(dst, ops), true
+}
+
+func (ps *PropSyncer) changed(op string, args []any, oldRevision, newRevision int64) {
+	_EmitEventPropChanged(ps, ps.self, op, args, oldRevision, newRevision)
+}
+
+func (ps *PropSyncer) persist(policy FlushPolicy) {
+	ps.flushPolicy = &policy
+}
+
+// Restore 异步从持久化存储恢复，恢复完成前未就绪，不会刷新至持久化存储，恢复失败时保持未就绪，完成后在运行时中回调，cb可以为nil
+func (ps *PropSyncer) Restore(cb func(err error)) {
+	ps.pending = true
+
+	ps.view.Restore(ps.entity.ID(), ps.name, func(data []byte, revision int64, err error) {
+		switch {
+		case err == nil:
+			err = ps.restored(data, revision)
+		case errors.Is(err, ErrPropDataNotFound):
+			err = nil
+			ps.pending = false
+		}
+		if cb != nil {
+			cb(err)
+		}
+	})
+}
+
+// restored 应用恢复的数据，恢复完成前的本地变化会被覆盖，以OpSnapshot通知属性变化
+func (ps *PropSyncer) restored(data []byte, revision int64) error {
+	managed := ps.managed()
+	oldRevision := managed.Revision()
+
+	if err := managed.Unmarshal(data, revision); err != nil {
+		log.L(runtime.Current(ps.entity)).Error("restore prop data failed",
+			zap.String("entity_id", ps.entity.ID().String()),
+			zap.String("prop", ps.name),
+			zap.Int64("revision", revision),
+			zap.Error(err))
+		return err
+	}
+
+	if oldRevision > 0 {
+		log.L(runtime.Current(ps.entity)).Warn("prop changed before restored, local changes are overwritten",
+			zap.String("entity_id", ps.entity.ID().String()),
+			zap.String("prop", ps.name),
+			zap.Int64("local_revision", oldRevision),
+			zap.Int64("revision", revision))
+	}
+
+	ps.history.init(len(ps.history.records))
+	ps.flushedRevision = revision
+	ps.pending = false
+	ps.changed(OpSnapshot, nil, oldRevision, revision)
+
+	return nil
+}
+
+// Ready 是否就绪，从持久化存储恢复完成前未就绪
+func (ps *PropSyncer) Ready() bool {
+	return !ps.pending
+}
+
+// Flush 刷新至持久化存储，未就绪、属性没有变化或相同版本正在写入时不会写入，写入成功后才更新已刷新的版本号
+func (ps *PropSyncer) Flush() error {
+	if ps.pending {
+		return nil
+	}
+
+	managed := ps.managed()
+
+	if managed.Revision() == ps.flushedRevision || managed.Revision() == ps.flushingRevision {
+		return nil
+	}
+
+	data, revision, err := managed.Marshal()
+	if err != nil {
+		return err
+	}
+
+	ps.flushingRevision = revision
+
+	ps.view.Flush(ps.entity.ID(), ps.name, data, revision, func(err error) {
+		if ps.flushingRevision == revision {
+			ps.flushingRevision = 0
+		}
+		if err != nil {
+			return
+		}
+		ps.flushedRevision = max(ps.flushedRevision, revision)
+	})
+
+	return nil
+}
+
+// Snapshot 以OpSnapshot同步全量数据至所有客户端目标，用于客户端镜像初始化或版本号断档后重新同步
+func (ps *PropSyncer) Snapshot() {
+	ps.snapshot(nil)
+}
+
+// snapshot 以OpSnapshot同步全量数据至客户端目标，filter为nil时同步至所有客户端目标，其他服务使用Save同步全量数据
+func (ps *PropSyncer) snapshot(filter func(dst string) bool) {
+	var syncTo []string
+	for _, dst := range ps.syncTo {
+		if !gate.ClientDetails.DomainRoot.Contains(dst) {
+			continue
+		}
+		if filter != nil && !filter(dst) {
+			continue
+		}
+		syncTo = append(syncTo, dst)
+	}
+
+	if len(syncTo) <= 0 {
+		return
+	}
+
+	managed := ps.managed()
+	ps.view.SyncSnapshot(ps.entity.ID(), ps.name, syncTo, ps.syncPolicy, managed.Revision(), managed.VariantState())
+}
+
+// SetSyncHistorySize 设置同步操作历史记录容量，版本号断档时优先补发缺失的操作，<=0表示不记录，总是同步全量数据
+func (ps *PropSyncer) SetSyncHistorySize(size int) {
+	ps.history.init(size)
+}
+
+// SetSyncPolicy 设置同步策略，nil表示所有操作同步至所有目标
+func (ps *PropSyncer) SetSyncPolicy(policy *SyncPolicy) {
+	ps.syncPolicy = policy
+}
+
+// EventPropChanged 事件：属性变化
+func (ps *PropSyncer) EventPropChanged() event.IEvent {
+	return &ps.eventPropChanged
+}
+
+// Entity 所属实体
+func (ps *PropSyncer) Entity() ec.Entity {
+	return ps.entity
+}
+
+// Name 属性名
+func (ps *PropSyncer) Name() string {
+	return ps.name
 }
 
 // ReflectedManaged 托管的属性反射值
@@ -87,3 +295,7 @@ func (ps *PropSyncer) ReflectedManaged() reflect.Value {
 func (ps *PropSyncer) Meta() *meta.Meta {
 	return &ps.meta
 }
+
+func (ps *PropSyncer) managed() IProp {
+	return ps.reflectedManaged.Interface().(IProp)
+}
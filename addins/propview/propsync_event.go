@@ -0,0 +1,29 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+//go:generate go run git.golaxy.org/core/event/eventc event
+package propview
+
+// OpSnapshot 全量数据覆盖属性时，属性变化事件使用的操作名，也用于向客户端同步全量数据
+const OpSnapshot = "$snapshot"
+
+// EventPropChanged 事件：属性变化，本地操作、远端同步的操作与全量数据覆盖后触发
+type EventPropChanged interface {
+	OnPropChanged(prop IPropSync, op string, args []any, oldRevision, newRevision int64)
+}
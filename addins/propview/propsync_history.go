@@ -0,0 +1,98 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package propview
+
+// DefaultSyncHistorySize 默认同步操作历史记录容量
+const DefaultSyncHistorySize = 64
+
+type _OpRecord struct {
+	revision int64
+	op       string
+	args     []any
+}
+
+// _OpHistory 最近同步操作的环形缓冲区，用于版本号断档时补发缺失的操作
+type _OpHistory struct {
+	records []_OpRecord
+	head    int
+	count   int
+}
+
+func (h *_OpHistory) init(size int) {
+	if size <= 0 {
+		h.records = nil
+	} else {
+		h.records = make([]_OpRecord, size)
+	}
+	h.head = 0
+	h.count = 0
+}
+
+func (h *_OpHistory) push(revision int64, op string, args []any) {
+	if len(h.records) <= 0 {
+		return
+	}
+
+	// 版本号不连续时（例如加载或保存了全量数据），丢弃旧记录
+	if h.count > 0 && h.at(h.count-1).revision+1 != revision {
+		h.head = 0
+		h.count = 0
+	}
+
+	idx := (h.head + h.count) % len(h.records)
+	h.records[idx] = _OpRecord{revision: revision, op: op, args: args}
+
+	if h.count < len(h.records) {
+		h.count++
+	} else {
+		h.head = (h.head + 1) % len(h.records)
+	}
+}
+
+func (h *_OpHistory) at(i int) *_OpRecord {
+	return &h.records[(h.head+i)%len(h.records)]
+}
+
+// replay 获取版本号from之后至revision的所有操作，格式与批量同步一致，缓冲区无法覆盖时返回false
+func (h *_OpHistory) replay(prop string, from, revision int64) ([]any, bool) {
+	if from == revision {
+		return nil, true
+	}
+
+	if from > revision || h.count <= 0 {
+		return nil, false
+	}
+
+	oldest := h.at(0).revision
+	newest := h.at(h.count - 1).revision
+
+	if from+1 < oldest || newest != revision {
+		return nil, false
+	}
+
+	ops := make([]any, 0, (revision-from)*4)
+
+	for i := int(from + 1 - oldest); i < h.count; i++ {
+		record := h.at(i)
+		ops = append(ops, prop, record.revision, record.op, record.args)
+	}
+
+	return ops, true
+}
@@ -0,0 +1,96 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package propview
+
+import (
+	"git.golaxy.org/framework/addins/gate"
+)
+
+// OpSkip 被同步策略过滤的操作，目标收到后只推进版本号，保证版本号连续
+const OpSkip = "$skip"
+
+// Visibility 操作可见性
+type Visibility int8
+
+const (
+	VisibleAll    Visibility = iota // 同步至所有目标
+	VisibleOwner                    // 只同步至其他服务与实体客户端
+	VisibleServer                   // 只同步至其他服务
+)
+
+// SyncPolicy 同步策略，决定操作同步至各目标时的可见性
+type SyncPolicy struct {
+	Visibility map[string]Visibility                          // 操作可见性，未配置的操作对所有目标可见
+	Filter     func(dst, op string, args []any) ([]any, bool) // 自定义过滤，返回false时目标只推进版本号，可以改写参数实现字段级可见性，不能修改传入的args
+}
+
+// ISyncPolicyProvider 提供默认同步策略，由propc根据注解生成
+type ISyncPolicyProvider interface {
+	// SyncPolicy 同步策略
+	SyncPolicy() *SyncPolicy
+}
+
+// apply 获取同步至目标的操作与参数
+func (p *SyncPolicy) apply(dst, op string, args []any) (string, []any) {
+	if p == nil {
+		return op, args
+	}
+
+	if !p.visible(dst, op) {
+		return OpSkip, []any{}
+	}
+
+	if p.Filter != nil {
+		filtered, ok := p.Filter(dst, op, args)
+		if !ok {
+			return OpSkip, []any{}
+		}
+		args = filtered
+	}
+
+	return op, args
+}
+
+func (p *SyncPolicy) visible(dst, op string) bool {
+	switch p.Visibility[op] {
+	case VisibleOwner:
+		return !gate.ClientDetails.DomainMulticast.Contains(dst)
+	case VisibleServer:
+		return !gate.ClientDetails.DomainRoot.Contains(dst)
+	default:
+		return true
+	}
+}
+
+// applyBatch 获取同步至目标的批量操作，格式与批量同步一致
+func (p *SyncPolicy) applyBatch(dst string, ops []any) []any {
+	if p == nil {
+		return ops
+	}
+
+	filtered := make([]any, 0, len(ops))
+
+	for i := 0; i+3 < len(ops); i += 4 {
+		op, args := p.apply(dst, ops[i+2].(string), ops[i+3].([]any))
+		filtered = append(filtered, ops[i], ops[i+1], op, args)
+	}
+
+	return filtered
+}
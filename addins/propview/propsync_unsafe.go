@@ -38,7 +38,7 @@ type _UnsafePropSync struct {
 
 // Init 初始化
 func (ps _UnsafePropSync) Init(view IPropView, entity ec.Entity, name string, reflectManaged reflect.Value, syncTo []string) {
-	ps.init(view, entity, name, reflectManaged, syncTo)
+	ps.init(ps.IPropSync, view, entity, name, reflectManaged, syncTo)
 }
 
 // Load 加载数据
@@ -20,8 +20,11 @@
 package propview
 
 import (
+	"git.golaxy.org/core/runtime"
 	"git.golaxy.org/core/utils/exception"
 	"git.golaxy.org/core/utils/generic"
+	"git.golaxy.org/core/utils/uid"
+	"git.golaxy.org/framework/addins/gate"
 )
 
 // IPropTab 属性表接口
@@ -67,6 +70,44 @@ func (pt *PropTab) EachProps(fun generic.Action2[string, IPropSync]) {
 	}
 }
 
+// DoPropSnapshot 客户端请求属性快照，entityID为uid.Nil或为本实体时同步至实体客户端，为同一运行时中的其他实体时同步至该实体属性的分组目标
+func (pt *PropTab) DoPropSnapshot(entityID uid.ID, propName string) error {
+	if len(*pt) <= 0 {
+		return ErrEntityNoProp
+	}
+
+	self, ok := (*pt)[0].V.(IPropSyncEx)
+	if !ok {
+		return ErrEntityNoProp
+	}
+
+	if entityID == uid.Nil || entityID == self.Entity().ID() {
+		prop, ok := pt.GetProp(propName).(iPropSyncer)
+		if !ok {
+			return ErrEntityNoProp
+		}
+		prop.snapshot(gate.ClientDetails.DomainUnicast.Equal)
+		return nil
+	}
+
+	entity, ok := runtime.Current(self.Entity()).EntityManager().GetEntity(entityID)
+	if !ok {
+		return ErrEntityNotFound
+	}
+
+	propTab, ok := entity.(IPropTab)
+	if !ok {
+		return ErrEntityNoPropTab
+	}
+
+	prop, ok := propTab.GetProp(propName).(iPropSyncer)
+	if !ok {
+		return ErrEntityNoProp
+	}
+	prop.snapshot(gate.ClientDetails.DomainMulticast.Contains)
+	return nil
+}
+
 func (pt *PropTab) toSliceMap() *generic.SliceMap[string, IPropSync] {
 	return (*generic.SliceMap[string, IPropSync])(pt)
 }
@@ -20,11 +20,16 @@
 package propview
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"git.golaxy.org/core"
+	"git.golaxy.org/core/ec"
 	"git.golaxy.org/core/runtime"
 	"git.golaxy.org/core/utils/async"
+	"git.golaxy.org/core/utils/generic"
+	"git.golaxy.org/core/utils/option"
 	"git.golaxy.org/core/utils/uid"
 	"git.golaxy.org/framework"
 	"git.golaxy.org/framework/addins/gate"
@@ -34,6 +39,7 @@ import (
 	"go.uber.org/zap"
 
 	"reflect"
+	"slices"
 )
 
 var (
@@ -47,6 +53,7 @@ var (
 	ErrMethodParameterTypeMismatch  = variant.Errorln(-9, "op method parameter type mismatch")
 	ErrLoadFromServiceItself        = variant.Errorln(-10, "can't load data from the service itself")
 	ErrSaveToServiceItself          = variant.Errorln(-11, "can't save data to the service itself")
+	ErrIncorrectSyncBatch           = variant.Errorln(-12, "incorrect sync batch")
 )
 
 // IPropView 属性视图插件接口
@@ -57,24 +64,71 @@ type IPropView interface {
 	Save(entityID uid.ID, prop string, service string, data []byte, revision int64) error
 	// Sync 同步属性变化
 	Sync(entityID uid.ID, prop string, syncTo []string, revision int64, op string, args ...any)
+	// SyncWithPolicy 按同步策略同步属性变化，policy为nil时与Sync相同
+	SyncWithPolicy(entityID uid.ID, prop string, syncTo []string, policy *SyncPolicy, revision int64, op string, args ...any)
+	// Restore 异步从持久化存储加载属性数据，完成后在运行时中回调
+	Restore(entityID uid.ID, prop string, cb func(data []byte, revision int64, err error))
+	// Flush 异步保存属性数据至持久化存储，完成后在运行时中回调，cb可以为nil
+	Flush(entityID uid.ID, prop string, data []byte, revision int64, cb func(err error))
+	// Persist 持久化托管属性，按刷新策略自动刷新至持久化存储
+	Persist(ps IPropSyncEx)
+	// SyncSnapshot 以OpSnapshot同步属性全量数据至客户端，先发送已缓存的批量同步，同步策略过滤后不可见的目标不发送
+	SyncSnapshot(entityID uid.ID, prop string, syncTo []string, policy *SyncPolicy, revision int64, state variant.Value)
 }
 
-func newPropView(...any) IPropView {
-	return &_PropView{}
+func newPropView(setting ...option.Setting[PropViewOptions]) IPropView {
+	return &_PropView{
+		options:   option.New(With.Default(), setting...),
+		persisted: map[uid.ID][]IPropSyncEx{},
+		resyncing: map[_ResyncKey]bool{},
+	}
 }
 
 type _PropView struct {
-	rt framework.IRuntime
+	rt          framework.IRuntime
+	options     PropViewOptions
+	persisted   map[uid.ID][]IPropSyncEx
+	syncBatches generic.SliceMap[_SyncBatchKey, *_SyncBatch]
+	resyncing   map[_ResyncKey]bool
+}
+
+type _ResyncKey struct {
+	entityID uid.ID
+	prop     string
+	dst      string
+}
+
+type _SyncBatchKey struct {
+	entityID uid.ID
+	dst      string
+}
+
+type _SyncBatch struct {
+	ops   []any
+	props []string
 }
 
 func (m *_PropView) Init(rtCtx runtime.Context) {
 	log.L(rtCtx).Info("initializing add-in", zap.String("name", AddIn.Name))
 
 	m.rt = framework.GetRuntime(rtCtx)
+
+	if m.options.Store != nil && m.options.FlushPolicy.Interval > 0 {
+		m.autoFlush()
+	}
 }
 
 func (m *_PropView) Shut(rtCtx runtime.Context) {
 	log.L(rtCtx).Info("shutting down add-in", zap.String("name", AddIn.Name))
+
+	m.flushSyncBatches()
+}
+
+func (m *_PropView) OnContextRunningEvent(ctx runtime.Context, runningEvent runtime.RunningEvent, args ...any) {
+	switch runningEvent {
+	case runtime.RunningEvent_FrameUpdateEnd:
+		m.flushSyncBatches()
+	}
 }
 
 func (m *_PropView) Load(entityID uid.ID, prop string, service string) ([]byte, int64, error) {
@@ -104,7 +158,18 @@ func (m *_PropView) Save(entityID uid.ID, prop string, service string, data []by
 }
 
 func (m *_PropView) Sync(entityID uid.ID, prop string, syncTo []string, revision int64, op string, args ...any) {
+	m.SyncWithPolicy(entityID, prop, syncTo, nil, revision, op, args...)
+}
+
+func (m *_PropView) SyncWithPolicy(entityID uid.ID, prop string, syncTo []string, policy *SyncPolicy, revision int64, op string, args ...any) {
+	if m.options.SyncBatching {
+		m.batchSync(entityID, prop, syncTo, policy, revision, op, args)
+		return
+	}
+
 	for _, dst := range syncTo {
+		op, args := policy.apply(dst, op, args)
+
 		if gate.ClientDetails.DomainUnicast.Equal(dst) {
 			// 同步至实体客户端
 			rpc.ProxyEntity(m.rt, entityID).CliOnewayRPC("", "DoSync", prop, revision, op, args)
@@ -123,6 +188,223 @@ func (m *_PropView) Sync(entityID uid.ID, prop string, syncTo []string, revision
 	}
 }
 
+func (m *_PropView) SyncSnapshot(entityID uid.ID, prop string, syncTo []string, policy *SyncPolicy, revision int64, state variant.Value) {
+	// 快照引用属性的状态值，不能缓存至帧结束，先发送已缓存的操作保证顺序
+	if m.options.SyncBatching {
+		m.flushSyncBatches()
+	}
+
+	for _, dst := range syncTo {
+		op, args := policy.apply(dst, OpSnapshot, []any{state})
+		if op == OpSkip {
+			log.L(m.rt).Warn("sync prop snapshot skipped, filtered by sync policy",
+				zap.String("entity_id", entityID.String()),
+				zap.String("prop", prop),
+				zap.String("dst", dst))
+			continue
+		}
+
+		if gate.ClientDetails.DomainUnicast.Equal(dst) {
+			// 同步至实体客户端
+			rpc.ProxyEntity(m.rt, entityID).CliOnewayRPC("", "DoSync", prop, revision, op, args)
+
+		} else if gate.ClientDetails.DomainMulticast.Contains(dst) {
+			// 同步至指定分组
+			group, _ := gate.ClientDetails.DomainMulticast.Relative(dst)
+			rpc.ProxyGroup(m.rt, dst).CliOnewayRPC(group, "DoSync", entityID, prop, revision, op, args)
+		}
+	}
+}
+
+func (m *_PropView) batchSync(entityID uid.ID, prop string, syncTo []string, policy *SyncPolicy, revision int64, op string, args []any) {
+	if m.syncBatches.Len() <= 0 && m.rt.Frame() == nil {
+		// 没有帧循环时，在当前任务结束后发送
+		m.rt.Post(func(runtime.Context, ...any) { m.flushSyncBatches() })
+	}
+
+	for _, dst := range syncTo {
+		key := _SyncBatchKey{entityID: entityID, dst: dst}
+
+		batch, ok := m.syncBatches.Get(key)
+		if !ok {
+			batch = &_SyncBatch{}
+			m.syncBatches.Add(key, batch)
+		}
+
+		op, args := policy.apply(dst, op, args)
+		batch.ops = append(batch.ops, prop, revision, op, args)
+
+		if !slices.Contains(batch.props, prop) {
+			batch.props = append(batch.props, prop)
+		}
+	}
+}
+
+func (m *_PropView) flushSyncBatches() {
+	if m.syncBatches.Len() <= 0 {
+		return
+	}
+
+	batches := m.syncBatches
+	m.syncBatches = nil
+
+	for _, kv := range batches {
+		entityID := kv.K.entityID
+		dst := kv.K.dst
+		batch := kv.V
+
+		if gate.ClientDetails.DomainUnicast.Equal(dst) {
+			// 同步至实体客户端
+			rpc.ProxyEntity(m.rt, entityID).CliOnewayRPC("", "DoSyncBatch", batch.ops)
+
+		} else if gate.ClientDetails.DomainMulticast.Contains(dst) {
+			// 同步至指定分组
+			group, _ := gate.ClientDetails.DomainMulticast.Relative(dst)
+			rpc.ProxyGroup(m.rt, dst).CliOnewayRPC(group, "DoSyncBatch", entityID, batch.ops)
+
+		} else if !gate.ClientDetails.DomainRoot.Contains(dst) {
+			// 同步至其他服务
+			core.ContinueOnVoid(m.rt,
+				rpc.ProxyRuntime(m.rt, entityID).RPC(dst, AddIn.Name, "DoSyncBatch", entityID, batch.ops),
+				m.doSyncBatchRet, dst, entityID, batch.props, len(batch.ops)/4)
+		}
+	}
+}
+
+func (m *_PropView) Restore(entityID uid.ID, prop string, cb func(data []byte, revision int64, err error)) {
+	if m.options.Store == nil {
+		log.L(m.rt).Error("restore prop data failed",
+			zap.String("entity_id", entityID.String()),
+			zap.String("prop", prop),
+			zap.Error(ErrStoreNotSet))
+		cb(nil, 0, ErrStoreNotSet)
+		return
+	}
+
+	async.SpawnVoid(m.rt.AsyncScope(), func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, m.options.StoreTimeout)
+		defer cancel()
+
+		data, revision, err := m.options.Store.Load(ctx, entityID, prop)
+		if err != nil {
+			if !errors.Is(err, ErrPropDataNotFound) {
+				log.L(m.rt).Error("restore prop data failed",
+					zap.String("entity_id", entityID.String()),
+					zap.String("prop", prop),
+					zap.Error(err))
+			}
+		} else {
+			log.L(m.rt).Debug("restore prop data ok",
+				zap.String("entity_id", entityID.String()),
+				zap.String("prop", prop),
+				zap.Int64("revision", revision))
+		}
+
+		m.rt.Post(func(runtime.Context, ...any) {
+			cb(data, revision, err)
+		})
+	})
+}
+
+func (m *_PropView) Flush(entityID uid.ID, prop string, data []byte, revision int64, cb func(err error)) {
+	if m.options.Store == nil {
+		log.L(m.rt).Error("flush prop data failed",
+			zap.String("entity_id", entityID.String()),
+			zap.String("prop", prop),
+			zap.Int64("revision", revision),
+			zap.Error(ErrStoreNotSet))
+		if cb != nil {
+			cb(ErrStoreNotSet)
+		}
+		return
+	}
+
+	async.SpawnVoid(m.rt.AsyncScope(), func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.options.StoreTimeout)
+		defer cancel()
+
+		err := m.options.Store.Save(ctx, entityID, prop, data, revision)
+		if err != nil {
+			log.L(m.rt).Error("flush prop data failed",
+				zap.String("entity_id", entityID.String()),
+				zap.String("prop", prop),
+				zap.Int64("revision", revision),
+				zap.Error(err))
+		} else {
+			log.L(m.rt).Debug("flush prop data ok",
+				zap.String("entity_id", entityID.String()),
+				zap.String("prop", prop),
+				zap.Int64("revision", revision))
+		}
+
+		if cb != nil {
+			m.rt.Post(func(runtime.Context, ...any) {
+				cb(err)
+			})
+		}
+	})
+}
+
+func (m *_PropView) Persist(ps IPropSyncEx) {
+	ps.persist(m.options.FlushPolicy)
+
+	entity := ps.Entity()
+
+	props, ok := m.persisted[entity.ID()]
+	if !ok {
+		ec.BindEventEntityDestroy(entity, ec.HandleEventEntityDestroy(m.onEntityDestroy))
+	}
+	m.persisted[entity.ID()] = append(props, ps)
+}
+
+func (m *_PropView) onEntityDestroy(entity ec.Entity) {
+	props, ok := m.persisted[entity.ID()]
+	if !ok {
+		return
+	}
+	delete(m.persisted, entity.ID())
+
+	if !m.options.FlushPolicy.OnShut {
+		return
+	}
+
+	for _, ps := range props {
+		m.flushProp(ps)
+	}
+}
+
+func (m *_PropView) autoFlush() {
+	async.SpawnVoid(m.rt.AsyncScope(), func(ctx context.Context) {
+		ticker := time.NewTicker(m.options.FlushPolicy.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			m.rt.Post(func(runtime.Context, ...any) {
+				for _, props := range m.persisted {
+					for _, ps := range props {
+						m.flushProp(ps)
+					}
+				}
+			})
+		}
+	})
+}
+
+func (m *_PropView) flushProp(ps IPropSyncEx) {
+	if err := ps.Flush(); err != nil {
+		log.L(m.rt).Error("flush prop data failed",
+			zap.String("entity_id", ps.Entity().ID().String()),
+			zap.String("prop", ps.Name()),
+			zap.Error(err))
+	}
+}
+
 func (m *_PropView) DoLoad(entityID uid.ID, propName string) ([]byte, int64, error) {
 	caller := m.rt.RPCStack().CallChain().Last()
 
@@ -225,6 +507,8 @@ func (m *_PropView) DoSave(entityID uid.ID, propName string, data []byte, revisi
 		return ErrEntityNoProp
 	}
 
+	oldRevision := prop.Managed().Revision()
+
 	err := prop.Managed().Unmarshal(data, revision)
 	if err != nil {
 		log.L(m.rt).Error("do save prop data failed",
@@ -238,6 +522,8 @@ func (m *_PropView) DoSave(entityID uid.ID, propName string, data []byte, revisi
 		return err
 	}
 
+	prop.changed(OpSnapshot, nil, oldRevision, revision)
+
 	log.L(m.rt).Info("do save prop data ok",
 		zap.String("entity_id", entityID.String()),
 		zap.String("prop", propName),
@@ -248,6 +534,48 @@ func (m *_PropView) DoSave(entityID uid.ID, propName string, data []byte, revisi
 	return nil
 }
 
+func (m *_PropView) DoRevision(entityID uid.ID, propName string) (int64, error) {
+	caller := m.rt.RPCStack().CallChain().Last()
+
+	entity, ok := m.rt.EntityManager().GetEntity(entityID)
+	if !ok {
+		log.L(m.rt).Error("do get prop revision failed",
+			zap.String("entity_id", entityID.String()),
+			zap.String("prop", propName),
+			zap.String("caller_svc", caller.Svc),
+			zap.String("caller_addr", caller.Addr),
+			zap.Error(ErrEntityNotFound),
+		)
+		return 0, ErrEntityNotFound
+	}
+
+	propTab, ok := entity.(IPropTab)
+	if !ok {
+		log.L(m.rt).Error("do get prop revision failed",
+			zap.String("entity_id", entityID.String()),
+			zap.String("prop", propName),
+			zap.String("caller_svc", caller.Svc),
+			zap.String("caller_addr", caller.Addr),
+			zap.Error(ErrEntityNoPropTab),
+		)
+		return 0, ErrEntityNoPropTab
+	}
+
+	prop := propTab.GetProp(propName)
+	if prop == nil {
+		log.L(m.rt).Error("do get prop revision failed",
+			zap.String("entity_id", entityID.String()),
+			zap.String("prop", propName),
+			zap.String("caller_svc", caller.Svc),
+			zap.String("caller_addr", caller.Addr),
+			zap.Error(ErrEntityNoProp),
+		)
+		return 0, ErrEntityNoProp
+	}
+
+	return prop.Managed().Revision(), nil
+}
+
 func (m *_PropView) DoSync(entityID uid.ID, propName string, revision int64, op string, argsRV []reflect.Value) error {
 	caller := m.rt.RPCStack().CallChain().Last()
 
@@ -293,6 +621,87 @@ func (m *_PropView) DoSync(entityID uid.ID, propName string, revision int64, op
 		return ErrEntityNoProp
 	}
 
+	return m.applyOp(entityID, propName, prop, revision, op, argsRV)
+}
+
+func (m *_PropView) DoSyncBatch(entityID uid.ID, opsRV []reflect.Value) error {
+	caller := m.rt.RPCStack().CallChain().Last()
+
+	entity, ok := m.rt.EntityManager().GetEntity(entityID)
+	if !ok {
+		log.L(m.rt).Error("do sync batch failed",
+			zap.String("entity_id", entityID.String()),
+			zap.String("caller_svc", caller.Svc),
+			zap.String("caller_addr", caller.Addr),
+			zap.Error(ErrEntityNotFound),
+		)
+		return ErrEntityNotFound
+	}
+
+	propTab, ok := entity.(IPropTab)
+	if !ok {
+		log.L(m.rt).Error("do sync batch failed",
+			zap.String("entity_id", entityID.String()),
+			zap.String("caller_svc", caller.Svc),
+			zap.String("caller_addr", caller.Addr),
+			zap.Error(ErrEntityNoPropTab),
+		)
+		return ErrEntityNoPropTab
+	}
+
+	if len(opsRV)%4 != 0 {
+		log.L(m.rt).Error("do sync batch failed",
+			zap.String("entity_id", entityID.String()),
+			zap.Int("ops", len(opsRV)),
+			zap.String("caller_svc", caller.Svc),
+			zap.String("caller_addr", caller.Addr),
+			zap.Error(ErrIncorrectSyncBatch),
+		)
+		return ErrIncorrectSyncBatch
+	}
+
+	for i := 0; i < len(opsRV); i += 4 {
+		propName, ok1 := batchValue[string](opsRV[i])
+		revision, ok2 := batchValue[int64](opsRV[i+1])
+		op, ok3 := batchValue[string](opsRV[i+2])
+		argsRV, ok4 := batchArgs(opsRV[i+3])
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			log.L(m.rt).Error("do sync batch failed",
+				zap.String("entity_id", entityID.String()),
+				zap.Int("index", i/4),
+				zap.String("caller_svc", caller.Svc),
+				zap.String("caller_addr", caller.Addr),
+				zap.Error(ErrIncorrectSyncBatch),
+			)
+			return ErrIncorrectSyncBatch
+		}
+
+		prop := propTab.GetProp(propName)
+		if prop == nil {
+			log.L(m.rt).Error("do sync batch failed",
+				zap.String("entity_id", entityID.String()),
+				zap.String("prop", propName),
+				zap.Int64("revision", revision),
+				zap.String("op", op),
+				zap.String("caller_svc", caller.Svc),
+				zap.String("caller_addr", caller.Addr),
+				zap.Error(ErrEntityNoProp),
+			)
+			return ErrEntityNoProp
+		}
+
+		// 按顺序应用，出错时中断，后续操作由发送方通过保存全量数据修复
+		if err := m.applyOp(entityID, propName, prop, revision, op, argsRV); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *_PropView) applyOp(entityID uid.ID, propName string, prop IPropSync, revision int64, op string, argsRV []reflect.Value) error {
+	caller := m.rt.RPCStack().CallChain().Last()
+
 	if revision <= prop.Managed().Revision() {
 		log.L(m.rt).Error("do sync op failed",
 			zap.String("entity_id", entityID.String()),
@@ -319,8 +728,16 @@ func (m *_PropView) DoSync(entityID uid.ID, propName string, revision int64, op
 		return ErrDiscontinuousRevision
 	}
 
-	methodRV := prop.ReflectedManaged().MethodByName(op)
-	if !methodRV.IsValid() {
+	var err error
+	switch applier, ok := prop.(IPropOpApplier); {
+	case op == OpSkip:
+		// 被同步策略过滤的操作，只推进版本号
+	case ok:
+		err = applier.ApplyOp(op, argsRV)
+	default:
+		err = callOp(prop.ReflectedManaged(), op, argsRV)
+	}
+	if err != nil {
 		log.L(m.rt).Error("do sync op failed",
 			zap.String("entity_id", entityID.String()),
 			zap.String("prop", propName),
@@ -328,86 +745,291 @@ func (m *_PropView) DoSync(entityID uid.ID, propName string, revision int64, op
 			zap.String("op", op),
 			zap.String("caller_svc", caller.Svc),
 			zap.String("caller_addr", caller.Addr),
-			zap.Error(ErrMethodNotFound),
+			zap.Error(err),
 		)
-		return ErrMethodNotFound
+		return opErrCode(err)
 	}
-	methodRT := methodRV.Type()
 
-	if methodRT.NumIn() != len(argsRV) {
-		log.L(m.rt).Error("do sync op failed",
+	prop.Managed().incrRevision()
+
+	if op != OpSkip {
+		prop.changed(op, opArgs(argsRV), revision-1, revision)
+	}
+
+	log.L(m.rt).Info("do sync op ok",
+		zap.String("entity_id", entityID.String()),
+		zap.String("prop", propName),
+		zap.Int64("revision", revision),
+		zap.String("op", op),
+		zap.String("caller_svc", caller.Svc),
+		zap.String("caller_addr", caller.Addr))
+	return nil
+}
+
+// resync 查询目标的属性版本号，补发缺失的操作，操作历史无法覆盖时同步全量数据，已在补发时标记为脏，补发结束后重新补发
+func (m *_PropView) resync(dst string, entityID uid.ID, propName string) {
+	key := _ResyncKey{entityID: entityID, prop: propName, dst: dst}
+
+	if _, ok := m.resyncing[key]; ok {
+		m.resyncing[key] = true
+		return
+	}
+	m.resyncing[key] = false
+
+	core.ContinueOnVoid(m.rt,
+		rpc.ProxyRuntime(m.rt, entityID).RPC(dst, AddIn.Name, "DoRevision", entityID, propName),
+		m.doRevisionRet, key)
+}
+
+func (m *_PropView) doRevisionRet(ctx runtime.Context, ret async.Result, args ...any) {
+	key := args[0].(_ResyncKey)
+
+	revision, err, retErr := rpc.Parse2[int64, error](ret).Extract()
+	if retErr != nil {
+		err = retErr
+	}
+	if err != nil {
+		log.L(m.rt).Warn("resync get prop revision failed, trying to save",
+			zap.String("entity_id", key.entityID.String()),
+			zap.String("prop", key.prop),
+			zap.String("dst", key.dst),
+			zap.Error(err),
+		)
+		m.resyncSave(key)
+		return
+	}
+
+	entity, ok := m.rt.EntityManager().GetEntity(key.entityID)
+	if !ok {
+		delete(m.resyncing, key)
+		log.L(m.rt).Error("resync failed",
+			zap.String("entity_id", key.entityID.String()),
+			zap.String("prop", key.prop),
+			zap.String("dst", key.dst),
+			zap.Error(ErrEntityNotFound),
+		)
+		return
+	}
+
+	prop := entity.(IPropTab).GetProp(key.prop)
+	if prop == nil {
+		delete(m.resyncing, key)
+		log.L(m.rt).Error("resync failed",
+			zap.String("entity_id", key.entityID.String()),
+			zap.String("prop", key.prop),
+			zap.String("dst", key.dst),
+			zap.Error(ErrEntityNoProp),
+		)
+		return
+	}
+
+	ops, ok := prop.replay(key.dst, revision)
+	if !ok {
+		log.L(m.rt).Warn("resync revision out of history, trying to save",
+			zap.String("entity_id", key.entityID.String()),
+			zap.String("prop", key.prop),
+			zap.String("dst", key.dst),
+			zap.Int64("dst_revision", revision),
+			zap.Int64("revision", prop.Managed().Revision()),
+		)
+		m.resyncSave(key)
+		return
+	}
+
+	if len(ops) <= 0 {
+		m.resyncDone(key)
+		return
+	}
+
+	core.ContinueOnVoid(m.rt,
+		rpc.ProxyRuntime(m.rt, key.entityID).RPC(key.dst, AddIn.Name, "DoSyncBatch", key.entityID, ops),
+		m.doResyncRet, key, revision, len(ops)/4)
+}
+
+func (m *_PropView) doResyncRet(ctx runtime.Context, ret async.Result, args ...any) {
+	key := args[0].(_ResyncKey)
+	from := args[1].(int64)
+	count := args[2].(int)
+
+	err, retErr := rpc.Parse1[error](ret).Extract()
+	if retErr != nil {
+		err = retErr
+	}
+	if err != nil {
+		log.L(m.rt).Warn("resync replay ops failed, trying to save",
+			zap.String("entity_id", key.entityID.String()),
+			zap.String("prop", key.prop),
+			zap.String("dst", key.dst),
+			zap.Int64("from", from),
+			zap.Int("ops", count),
+			zap.Error(err),
+		)
+		m.resyncSave(key)
+		return
+	}
+
+	log.L(m.rt).Info("resync replay ops ok",
+		zap.String("entity_id", key.entityID.String()),
+		zap.String("prop", key.prop),
+		zap.String("dst", key.dst),
+		zap.Int64("from", from),
+		zap.Int("ops", count))
+
+	m.resyncDone(key)
+}
+
+func (m *_PropView) resyncSave(key _ResyncKey) {
+	defer m.resyncDone(key)
+
+	entity, ok := m.rt.EntityManager().GetEntity(key.entityID)
+	if !ok {
+		log.L(m.rt).Error("resync trying to save failed",
+			zap.String("entity_id", key.entityID.String()),
+			zap.String("prop", key.prop),
+			zap.String("dst", key.dst),
+			zap.Error(ErrEntityNotFound),
+		)
+		return
+	}
+
+	prop := entity.(IPropTab).GetProp(key.prop)
+	if prop == nil {
+		log.L(m.rt).Error("resync trying to save failed",
+			zap.String("entity_id", key.entityID.String()),
+			zap.String("prop", key.prop),
+			zap.String("dst", key.dst),
+			zap.Error(ErrEntityNoProp),
+		)
+		return
+	}
+
+	if err := prop.Save(key.dst); err != nil {
+		log.L(m.rt).Error("resync trying to save failed",
+			zap.String("entity_id", key.entityID.String()),
+			zap.String("prop", key.prop),
+			zap.String("dst", key.dst),
+			zap.Error(err))
+		return
+	}
+
+	log.L(m.rt).Info("resync trying to save ok",
+		zap.String("entity_id", key.entityID.String()),
+		zap.String("prop", key.prop),
+		zap.String("dst", key.dst))
+}
+
+// resyncDone 补发结束，补发期间出现新的断档时重新补发
+func (m *_PropView) resyncDone(key _ResyncKey) {
+	dirty := m.resyncing[key]
+	delete(m.resyncing, key)
+
+	if dirty {
+		m.resync(key.dst, key.entityID, key.prop)
+	}
+}
+
+func (m *_PropView) doSyncBatchRet(ctx runtime.Context, ret async.Result, args ...any) {
+	err, retErr := rpc.Parse1[error](ret).Extract()
+	if err == nil && retErr == nil {
+		return
+	}
+
+	dst := args[0].(string)
+	entityID := args[1].(uid.ID)
+	propNames := args[2].([]string)
+	ops := args[3].(int)
+
+	if retErr != nil {
+		log.L(m.rt).Error("sync batch failed",
 			zap.String("entity_id", entityID.String()),
-			zap.String("prop", propName),
-			zap.Int64("revision", revision),
-			zap.String("op", op),
-			zap.String("caller_svc", caller.Svc),
-			zap.String("caller_addr", caller.Addr),
-			zap.Error(ErrMethodParameterCountMismatch),
+			zap.Strings("props", propNames),
+			zap.Int("ops", ops),
+			zap.String("dst", dst),
+			zap.Error(retErr),
 		)
-		return ErrMethodParameterCountMismatch
+		return
 	}
 
-	for i := range argsRV {
-		argRV := argsRV[i]
-		argRT := argRV.Type()
-		paramRT := methodRT.In(i)
+	var syncErr *variant.Error
 
-	retry:
-		if argRT.AssignableTo(paramRT) {
-			argsRV[i] = argRV
-			continue
+	if ok := errors.As(err, &syncErr); !ok {
+		log.L(m.rt).Error("sync batch failed",
+			zap.String("entity_id", entityID.String()),
+			zap.Strings("props", propNames),
+			zap.Int("ops", ops),
+			zap.String("dst", dst),
+			zap.Error(err),
+		)
+		return
+	}
+
+	switch syncErr.Code {
+	case ErrOutdatedRevision.Code, ErrDiscontinuousRevision.Code:
+		log.L(m.rt).Warn("sync batch failed, trying to resync",
+			zap.String("entity_id", entityID.String()),
+			zap.Strings("props", propNames),
+			zap.Int("ops", ops),
+			zap.String("dst", dst),
+			zap.NamedError("sync_err", err),
+		)
+		for _, propName := range propNames {
+			m.resync(dst, entityID, propName)
 		}
+		return
 
-		if argRV.CanConvert(paramRT) {
-			if argRT.Size() > paramRT.Size() {
-				log.L(m.rt).Error("do sync op failed",
+	case ErrEntityNoProp.Code, ErrMethodNotFound.Code, ErrMethodParameterCountMismatch.Code, ErrMethodParameterTypeMismatch.Code:
+		log.L(m.rt).Warn("sync batch failed, trying to save",
+			zap.String("entity_id", entityID.String()),
+			zap.Strings("props", propNames),
+			zap.Int("ops", ops),
+			zap.String("dst", dst),
+			zap.NamedError("sync_err", err),
+		)
+
+		entity, ok := m.rt.EntityManager().GetEntity(entityID)
+		if !ok {
+			log.L(m.rt).Error("sync batch trying to save failed",
+				zap.String("entity_id", entityID.String()),
+				zap.Strings("props", propNames),
+				zap.String("dst", dst),
+				zap.Error(ErrEntityNotFound),
+			)
+			return
+		}
+
+		// 无法确定中断位置，批次中涉及的属性全部保存
+		for _, propName := range propNames {
+			prop := entity.(IPropTab).GetProp(propName)
+			if prop == nil {
+				continue
+			}
+
+			if err := prop.Save(dst); err != nil {
+				log.L(m.rt).Error("sync batch trying to save failed",
 					zap.String("entity_id", entityID.String()),
 					zap.String("prop", propName),
-					zap.Int64("revision", revision),
-					zap.String("op", op),
-					zap.String("caller_svc", caller.Svc),
-					zap.String("caller_addr", caller.Addr),
-					zap.Error(ErrMethodParameterTypeMismatch),
-				)
-				return ErrMethodParameterTypeMismatch
+					zap.String("dst", dst),
+					zap.Error(err))
+				continue
 			}
-			argsRV[i] = argRV.Convert(paramRT)
-			continue
-		}
 
-		if argRT.Kind() == reflect.Pointer {
-			argRV = argRV.Elem()
-			argRT = argRV.Type()
-			goto retry
-		}
-
-		argRV, err := argsRV[i].Interface().(variant.Variant).ToNative(paramRT)
-		if err != nil {
-			log.L(m.rt).Error("do sync op failed",
+			log.L(m.rt).Info("sync batch trying to save ok",
 				zap.String("entity_id", entityID.String()),
 				zap.String("prop", propName),
-				zap.Int64("revision", revision),
-				zap.String("op", op),
-				zap.String("caller_svc", caller.Svc),
-				zap.String("caller_addr", caller.Addr),
-				zap.Error(err))
-			return ErrMethodParameterTypeMismatch
+				zap.String("dst", dst))
 		}
+		return
 
-		argsRV[i] = argRV
+	default:
+		log.L(m.rt).Error("sync batch failed",
+			zap.String("entity_id", entityID.String()),
+			zap.Strings("props", propNames),
+			zap.Int("ops", ops),
+			zap.String("dst", dst),
+			zap.Error(err),
+		)
+		return
 	}
-
-	methodRV.Call(argsRV)
-	prop.Managed().incrRevision()
-
-	log.L(m.rt).Info("do sync op ok",
-		zap.String("entity_id", entityID.String()),
-		zap.String("prop", propName),
-		zap.Int64("revision", revision),
-		zap.String("op", op),
-		zap.String("caller_svc", caller.Svc),
-		zap.String("caller_addr", caller.Addr))
-	return nil
 }
 
 func (m *_PropView) doSyncRet(ctx runtime.Context, ret async.Result, args ...any) {
@@ -449,7 +1071,19 @@ func (m *_PropView) doSyncRet(ctx runtime.Context, ret async.Result, args ...any
 	}
 
 	switch syncErr.Code {
-	case ErrOutdatedRevision.Code, ErrDiscontinuousRevision.Code, ErrMethodNotFound.Code, ErrMethodParameterCountMismatch.Code, ErrMethodParameterTypeMismatch.Code:
+	case ErrOutdatedRevision.Code, ErrDiscontinuousRevision.Code:
+		log.L(m.rt).Warn("sync op failed, trying to resync",
+			zap.String("entity_id", entityID.String()),
+			zap.String("prop", propName),
+			zap.Int64("revision", revision),
+			zap.String("op", op),
+			zap.String("dst", dst),
+			zap.NamedError("sync_err", err),
+		)
+		m.resync(dst, entityID, propName)
+		return
+
+	case ErrMethodNotFound.Code, ErrMethodParameterCountMismatch.Code, ErrMethodParameterTypeMismatch.Code:
 		log.L(m.rt).Warn("sync op failed, trying to save",
 			zap.String("entity_id", entityID.String()),
 			zap.String("prop", propName),
@@ -504,3 +1138,46 @@ func (m *_PropView) doSyncRet(ctx runtime.Context, ret async.Result, args ...any
 		return
 	}
 }
+
+func batchValue[T any](rv reflect.Value) (T, bool) {
+	if v, ok := rv.Interface().(T); ok {
+		return v, true
+	}
+
+	if v, ok := rv.Interface().(variant.Variant); ok {
+		nativeRV, err := v.ToNative(reflect.TypeFor[T]())
+		if err == nil {
+			if nv, ok := nativeRV.Interface().(T); ok {
+				return nv, true
+			}
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+func batchArgs(rv reflect.Value) ([]reflect.Value, bool) {
+	switch v := rv.Interface().(type) {
+	case []reflect.Value:
+		return v, true
+	case []any:
+		argsRV := make([]reflect.Value, 0, len(v))
+		for _, arg := range v {
+			argsRV = append(argsRV, reflect.ValueOf(arg))
+		}
+		return argsRV, true
+	case variant.Variant:
+		arr, ok := v.Value.(variant.Array)
+		if !ok {
+			return nil, false
+		}
+		argsRV := make([]reflect.Value, 0, len(arr))
+		for _, arg := range arr {
+			argsRV = append(argsRV, reflect.ValueOf(arg))
+		}
+		return argsRV, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package propview
+
+import (
+	"time"
+
+	"git.golaxy.org/core"
+	"git.golaxy.org/core/utils/exception"
+	"git.golaxy.org/core/utils/option"
+)
+
+// PropViewOptions 所有选项
+type PropViewOptions struct {
+	Store        IPropStore    // 持久化存储
+	FlushPolicy  FlushPolicy   // 持久化刷新策略
+	StoreTimeout time.Duration // 持久化存储读写超时时间
+	SyncBatching bool          // 缓存同一帧内的同步操作，按实体与目标批量发送，不合并同一属性的操作
+}
+
+var With _Option
+
+type _Option struct{}
+
+// Default 默认值
+func (_Option) Default() option.Setting[PropViewOptions] {
+	return func(options *PropViewOptions) {
+		With.Store(nil).Apply(options)
+		With.FlushPolicy(FlushPolicy{Interval: time.Minute, OnShut: true}).Apply(options)
+		With.StoreTimeout(10 * time.Second).Apply(options)
+		With.SyncBatching(false).Apply(options)
+	}
+}
+
+// Store 持久化存储
+func (_Option) Store(store IPropStore) option.Setting[PropViewOptions] {
+	return func(options *PropViewOptions) {
+		options.Store = store
+	}
+}
+
+// FlushPolicy 持久化刷新策略
+func (_Option) FlushPolicy(policy FlushPolicy) option.Setting[PropViewOptions] {
+	return func(options *PropViewOptions) {
+		if policy.Interval > 0 && policy.Interval < time.Second {
+			exception.Panicf("propview: %w: option FlushPolicy.Interval can't be set to a value less than 1 second", core.ErrArgs)
+		}
+		options.FlushPolicy = policy
+	}
+}
+
+// StoreTimeout 持久化存储读写超时时间
+func (_Option) StoreTimeout(d time.Duration) option.Setting[PropViewOptions] {
+	return func(options *PropViewOptions) {
+		if d <= 0 {
+			exception.Panicf("propview: %w: option StoreTimeout can't be set to a value less equal 0", core.ErrArgs)
+		}
+		options.StoreTimeout = d
+	}
+}
+
+// SyncBatching 合并同一帧内的同步操作，按实体与目标批量发送
+func (_Option) SyncBatching(b bool) option.Setting[PropViewOptions] {
+	return func(options *PropViewOptions) {
+		options.SyncBatching = b
+	}
+}
@@ -0,0 +1,136 @@
+# This file is part of Golaxy Distributed Service Development Framework.
+#
+# Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+# it under the terms of the GNU Lesser General Public License as published by
+# the Free Software Foundation, either version 2.1 of the License, or
+# (at your option) any later version.
+#
+# Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+# but WITHOUT ANY WARRANTY; without even the implied warranty of
+# MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+# GNU Lesser General Public License for more details.
+#
+# You should have received a copy of the GNU Lesser General Public License
+# along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+#
+# Copyright (c) 2024 pangdogs.
+#
+class_name GolaxyPropMirror
+extends RefCounted
+
+signal changed(revision: int, op: String, args: Array)
+signal desynced(revision: int, op: String, error: int)
+
+# ops filtered by the server sync policy, only advance the revision
+const OP_SKIP := "$skip"
+# full state sent by the server on request, args is [state], replaces the state and resets the revision
+const OP_SNAPSHOT := "$snapshot"
+# a pending snapshot request is sent again after this long without a reply
+const SNAPSHOT_RETRY_MS := 3000
+
+# error codes, same as addins/propview
+const ERR_OUTDATED_REVISION := -5
+const ERR_DISCONTINUOUS_REVISION := -6
+const ERR_METHOD_NOT_FOUND := -7
+const ERR_METHOD_PARAMETER_COUNT_MISMATCH := -8
+const ERR_METHOD_PARAMETER_TYPE_MISMATCH := -9
+
+var _entity_id := ""
+var _name := ""
+var _revision: int = 0
+var _snapshot_requested_ms: int = -1
+
+func _init(name: String, entity_id: String = "") -> void:
+	_name = name
+	_entity_id = entity_id
+
+func entity_id() -> String:
+	return _entity_id
+
+func name() -> String:
+	return _name
+
+func revision() -> int:
+	return _revision
+
+func reset(revision: int = 0) -> void:
+	_revision = revision
+
+# snapshot_pending returns whether a snapshot was requested and has not been applied yet
+func snapshot_pending() -> bool:
+	return _snapshot_requested_ms >= 0 and Time.get_ticks_msec() - _snapshot_requested_ms < SNAPSHOT_RETRY_MS
+
+func mark_snapshot_requested(requested: bool = true) -> void:
+	_snapshot_requested_ms = Time.get_ticks_msec() if requested else -1
+
+func apply_op(revision: int, op: String, args: Array) -> int:
+	if op == OP_SNAPSHOT:
+		return apply_snapshot(revision, args)
+	if revision <= _revision:
+		return ERR_OUTDATED_REVISION
+	if revision != _revision + 1:
+		if not snapshot_pending():
+			desynced.emit(revision, op, ERR_DISCONTINUOUS_REVISION)
+		return ERR_DISCONTINUOUS_REVISION
+	if op == OP_SKIP:
+		_revision = revision
+		return OK
+	var err := _apply_op(op, args)
+	if err != OK:
+		desynced.emit(revision, op, err)
+		return err
+	_revision = revision
+	changed.emit(revision, op, args)
+	return OK
+
+func apply_snapshot(revision: int, args: Array) -> int:
+	# snapshots requested by other group members reach every member, skip stale ones
+	if revision < _revision and _snapshot_requested_ms < 0:
+		return ERR_OUTDATED_REVISION
+	var err := _apply_snapshot(args)
+	if err != OK:
+		desynced.emit(revision, OP_SNAPSHOT, err)
+		return err
+	_revision = revision
+	_snapshot_requested_ms = -1
+	changed.emit(revision, OP_SNAPSHOT, args)
+	return OK
+
+func _apply_op(op: String, args: Array) -> int:
+	return ERR_METHOD_NOT_FOUND
+
+func _apply_snapshot(args: Array) -> int:
+	return ERR_METHOD_NOT_FOUND
+
+static func _check_args(args: Array, types: Array) -> int:
+	if args.size() != types.size():
+		return ERR_METHOD_PARAMETER_COUNT_MISMATCH
+	for i in range(types.size()):
+		var type: int = types[i]
+		if type == TYPE_NIL:
+			continue
+		var arg_type := typeof(args[i])
+		if arg_type == type:
+			continue
+		if type == TYPE_FLOAT and arg_type == TYPE_INT:
+			args[i] = float(args[i])
+			continue
+		return ERR_METHOD_PARAMETER_TYPE_MISMATCH
+	return OK
+
+static func error_message(error: int) -> String:
+	match error:
+		OK:
+			return ""
+		ERR_OUTDATED_REVISION:
+			return "synchronized revision is outdated"
+		ERR_DISCONTINUOUS_REVISION:
+			return "synchronized revision is discontinuous"
+		ERR_METHOD_NOT_FOUND:
+			return "op method not found"
+		ERR_METHOD_PARAMETER_COUNT_MISMATCH:
+			return "op method parameter count mismatch"
+		ERR_METHOD_PARAMETER_TYPE_MISMATCH:
+			return "op method parameter type mismatch"
+		_:
+			return "unknown error %d" % error
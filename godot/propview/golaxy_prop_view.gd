@@ -0,0 +1,174 @@
+# This file is part of Golaxy Distributed Service Development Framework.
+#
+# Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+# it under the terms of the GNU Lesser General Public License as published by
+# the Free Software Foundation, either version 2.1 of the License, or
+# (at your option) any later version.
+#
+# Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+# but WITHOUT ANY WARRANTY; without even the implied warranty of
+# MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+# GNU Lesser General Public License for more details.
+#
+# You should have received a copy of the GNU Lesser General Public License
+# along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+#
+# Copyright (c) 2024 pangdogs.
+#
+class_name GolaxyPropView
+extends Node
+
+signal prop_changed(entity_id: String, prop: String, revision: int, op: String, args: Array)
+signal prop_desynced(entity_id: String, prop: String, revision: int, op: String, error: int)
+signal snapshot_requested(entity_id: String, prop: String)
+
+# unicast ops carry no entity id, mirrors of the client's own entity use this key
+const SELF_ENTITY := ""
+
+var _rpcli: GolaxyRPCLI = null
+var _snapshot_service := ""
+var _groups: Array[String] = []
+var _mirrors: Dictionary[String, Dictionary] = {}
+var _logger := GolaxyLogger.new("GolaxyPropView", get_instance_id())
+
+var logger: GolaxyLogger:
+	get:
+		return _logger
+
+func _exit_tree() -> void:
+	detach()
+
+# snapshot_service is the service of the client's entity, which serves DoPropSnapshot through propview.PropTab;
+# when set, mirrors request a snapshot on attach, on add and after a discontinuous revision
+func attach(rpcli: GolaxyRPCLI, groups: Array[String] = [], snapshot_service: String = "") -> bool:
+	detach()
+	if rpcli == null:
+		return false
+	_rpcli = rpcli
+	_logger = rpcli.logger.named("GolaxyPropView", get_instance_id())
+	if not _rpcli.bind(SELF_ENTITY, self):
+		_rpcli = null
+		return false
+	_snapshot_service = snapshot_service
+	for group in groups:
+		attach_group(group)
+	for props in _mirrors.values():
+		for mirror in props.values():
+			request_snapshot(mirror)
+	return true
+
+func detach() -> void:
+	if _rpcli == null:
+		return
+	for group in _groups:
+		_rpcli.unbind(group, get_instance_id())
+	_rpcli.unbind(SELF_ENTITY, get_instance_id())
+	_groups.clear()
+	_rpcli = null
+	_snapshot_service = ""
+
+func attach_group(group: String) -> bool:
+	if _rpcli == null or group.is_empty() or _groups.has(group):
+		return false
+	if not _rpcli.bind(group, self):
+		return false
+	_groups.append(group)
+	return true
+
+func detach_group(group: String) -> void:
+	if _rpcli == null or not _groups.has(group):
+		return
+	_rpcli.unbind(group, get_instance_id())
+	_groups.erase(group)
+
+func add_mirror(mirror: GolaxyPropMirror) -> bool:
+	if mirror == null:
+		return false
+	var props := _mirrors.get(mirror.entity_id(), {}) as Dictionary
+	if props.has(mirror.name()):
+		_logger.warning("prop mirror already exists, entity_id=%s, prop=%s", [mirror.entity_id(), mirror.name()])
+		return false
+	props[mirror.name()] = mirror
+	_mirrors[mirror.entity_id()] = props
+	request_snapshot(mirror)
+	return true
+
+func remove_mirror(entity_id: String, prop: String) -> void:
+	var props := _mirrors.get(entity_id, {}) as Dictionary
+	props.erase(prop)
+	if props.is_empty():
+		_mirrors.erase(entity_id)
+
+func remove_entity(entity_id: String) -> void:
+	_mirrors.erase(entity_id)
+
+func get_mirror(entity_id: String, prop: String) -> GolaxyPropMirror:
+	var props := _mirrors.get(entity_id, {}) as Dictionary
+	return props.get(prop, null) as GolaxyPropMirror
+
+# request_snapshot asks the server to send the full state of the mirror as a $snapshot op, one request at a time per mirror
+func request_snapshot(mirror: GolaxyPropMirror) -> bool:
+	if _rpcli == null or _snapshot_service.is_empty() or mirror == null or mirror.snapshot_pending():
+		return false
+	if not _rpcli.oneway_rpc(_snapshot_service, "", "DoPropSnapshot", [mirror.entity_id(), mirror.name()]):
+		_logger.error("request prop snapshot failed, entity_id=%s, prop=%s", [mirror.entity_id(), mirror.name()])
+		return false
+	mirror.mark_snapshot_requested()
+	if _logger.debug_enabled:
+		_logger.debug("request prop snapshot ok, entity_id=%s, prop=%s, revision=%d", [mirror.entity_id(), mirror.name(), mirror.revision()])
+	snapshot_requested.emit(mirror.entity_id(), mirror.name())
+	return true
+
+# DoSync unicast: (prop, revision, op, args), multicast: (entity_id, prop, revision, op, args)
+func DoSync(arg0: Variant, arg1: Variant, arg2: Variant, arg3: Variant, arg4: Variant = null) -> void:
+	if arg4 == null:
+		_apply_op(SELF_ENTITY, arg0, arg1, arg2, arg3)
+	else:
+		_apply_op(arg0, arg1, arg2, arg3, arg4)
+
+# DoSyncBatch unicast: (ops), multicast: (entity_id, ops); ops is a flat list of (prop, revision, op, args)
+func DoSyncBatch(arg0: Variant, arg1: Variant = null) -> void:
+	var entity_id: Variant = SELF_ENTITY
+	var ops: Variant = arg0
+	if arg1 != null:
+		entity_id = arg0
+		ops = arg1
+
+	if typeof(ops) != TYPE_ARRAY or (ops as Array).size() % 4 != 0:
+		_logger.error("do sync batch failed, incorrect sync batch, entity_id=%s", [entity_id])
+		return
+
+	var batch := ops as Array
+	for i in range(0, batch.size(), 4):
+		if _apply_op(entity_id, batch[i], batch[i + 1], batch[i + 2], batch[i + 3]) != OK:
+			return
+
+func _apply_op(entity_id: Variant, prop: Variant, revision: Variant, op: Variant, args: Variant) -> int:
+	if typeof(entity_id) != TYPE_STRING or typeof(prop) != TYPE_STRING or typeof(revision) != TYPE_INT or typeof(op) != TYPE_STRING or typeof(args) != TYPE_ARRAY:
+		_logger.error("do sync op failed, incorrect args, entity_id=%s, prop=%s, revision=%s, op=%s", [entity_id, prop, revision, op])
+		return GolaxyPropMirror.ERR_METHOD_PARAMETER_TYPE_MISMATCH
+
+	var mirror := get_mirror(entity_id, prop)
+	if mirror == null:
+		if _logger.debug_enabled:
+			_logger.debug("do sync op skipped, prop mirror not found, entity_id=%s, prop=%s, revision=%d, op=%s", [entity_id, prop, revision, op])
+		return OK
+
+	var err := mirror.apply_op(revision, op, args)
+	if err != OK:
+		if err == GolaxyPropMirror.ERR_DISCONTINUOUS_REVISION and mirror.snapshot_pending():
+			# ops arriving before the requested snapshot are expected to be discontinuous
+			return err
+		_logger.error("do sync op failed, entity_id=%s, prop=%s, revision=%d, op=%s, error=%s", [entity_id, prop, revision, op, GolaxyPropMirror.error_message(err)])
+		prop_desynced.emit(entity_id, prop, revision, op, err)
+		if err == GolaxyPropMirror.ERR_DISCONTINUOUS_REVISION:
+			request_snapshot(mirror)
+		return err
+
+	if op == GolaxyPropMirror.OP_SKIP:
+		return OK
+
+	if _logger.debug_enabled:
+		_logger.debug("do sync op ok, entity_id=%s, prop=%s, revision=%d, op=%s, args=%s", [entity_id, prop, revision, op, args])
+	prop_changed.emit(entity_id, prop, revision, op, args)
+	return OK
@@ -0,0 +1,465 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"git.golaxy.org/scaffold/addins/goscr"
+	"git.golaxy.org/scaffold/addins/goscr/dynamic"
+	"git.golaxy.org/scaffold/addins/goscr/fwlib"
+	"github.com/pangdogs/yaegi/stdlib"
+)
+
+// Severity 诊断级别
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// 诊断代码
+const (
+	CodeLoad               = "load"                // 加载失败
+	CodeCompile            = "compile"             // 编译错误
+	CodeSymbolPolicy       = "symbol-policy"       // 违反符号访问策略
+	CodeUnresolvedScript   = "unresolved-script"   // 无法找到原型绑定的脚本
+	CodeUnresolvedThis     = "unresolved-this"     // 无法解析This类型
+	CodeBindMode           = "bind-mode"           // 绑定模式错误
+	CodeLifecycleSignature = "lifecycle-signature" // 生命周期方法签名错误
+)
+
+// Diagnostic 诊断信息
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+}
+
+// String 格式化为<文件>:<行>:<列>: <级别>: <信息> (<代码>)
+func (diag Diagnostic) String() string {
+	pos := diag.File
+	if diag.Line > 0 {
+		pos += ":" + strconv.Itoa(diag.Line)
+		if diag.Column > 0 {
+			pos += ":" + strconv.Itoa(diag.Column)
+		}
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", pos, diag.Severity, diag.Message, diag.Code)
+}
+
+// Project 脚本工程
+type Project struct {
+	ScriptRoot string // 脚本根路径
+	Dir        string // 本地目录
+}
+
+// Checker 脚本工程检查器
+type Checker struct {
+	PkgRoot    string                // 包根路径
+	Projects   []*Project            // 脚本工程列表
+	Policy     *dynamic.SymbolPolicy // 符号访问策略
+	Entities   []string              // 实体原型绑定的脚本，格式为<script_pkg>.<script_ident>，与goscr.EntityScript相同
+	Components []string              // 组件原型绑定的脚本，格式为<script_pkg>.<script_ident>，与goscr.ComponentScript相同
+	fset       *token.FileSet
+	diags      []Diagnostic
+	typeIdx    map[string]*_TypeDecl
+	failed     map[*Project]bool
+}
+
+var errorPosRegexp = regexp.MustCompile(`(?m)([^\s:"]+\.go):(\d+):(\d+):\s*(.*)$`)
+
+// Check 检查所有脚本工程，返回按位置排序的诊断信息
+func (c *Checker) Check() []Diagnostic {
+	c.fset = token.NewFileSet()
+	c.diags = nil
+	c.typeIdx = map[string]*_TypeDecl{}
+	c.failed = map[*Project]bool{}
+
+	var solution *dynamic.Solution
+	if c.Policy != nil {
+		solution = dynamic.NewSandboxedSolution(c.PkgRoot, c.Policy)
+	} else {
+		solution = dynamic.NewSolution(c.PkgRoot)
+	}
+	solution.Use(stdlib.Symbols)
+	solution.Use(fwlib.Symbols)
+
+	for _, project := range c.Projects {
+		err := solution.Load(&dynamic.Project{ScriptRoot: project.ScriptRoot, LocalPath: project.Dir})
+		if err != nil {
+			c.reportLoadError(project, err)
+			c.failed[project] = true
+		}
+	}
+
+	for _, project := range c.Projects {
+		c.parseProject(project)
+	}
+
+	c.checkBindings(solution)
+
+	slices.SortStableFunc(c.diags, func(a, b Diagnostic) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+
+	return c.diags
+}
+
+func (c *Checker) report(pos token.Position, severity Severity, code, format string, args ...any) {
+	c.diags = append(c.diags, Diagnostic{
+		File:     pos.Filename,
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// reportLoadError 报告加载错误，从错误信息中提取代码位置并映射回工程目录
+func (c *Checker) reportLoadError(project *Project, err error) {
+	code := CodeCompile
+	if errors.Is(err, dynamic.ErrSymbolPolicy) {
+		code = CodeSymbolPolicy
+	}
+
+	matches := errorPosRegexp.FindAllStringSubmatch(err.Error(), -1)
+	if len(matches) <= 0 {
+		c.report(token.Position{Filename: project.Dir}, SeverityError, CodeLoad, "%s", err)
+		return
+	}
+
+	for _, match := range matches {
+		line, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		pos := token.Position{Filename: c.localPath(match[1]), Line: line, Column: column}
+		c.report(pos, SeverityError, code, "%s", match[4])
+	}
+}
+
+// localPath 将解决方案中的代码路径映射为工程目录中的文件路径
+func (c *Checker) localPath(codePath string) string {
+	codePath = strings.TrimPrefix(path.Clean(filepath.ToSlash(codePath)), "/")
+	codePath = strings.TrimPrefix(codePath, "src/main/vendor/")
+
+	for _, project := range c.Projects {
+		scriptPath := path.Join(c.PkgRoot, project.ScriptRoot)
+		if rel, ok := strings.CutPrefix(codePath, scriptPath+"/"); ok {
+			return filepath.Join(project.Dir, filepath.FromSlash(rel))
+		}
+	}
+
+	return codePath
+}
+
+type _TypeDecl struct {
+	project *Project
+	pkgPath string
+	file    *ast.File
+	spec    *ast.TypeSpec
+	methods []*ast.FuncDecl
+}
+
+// parseProject 解析工程代码，收集类型声明与方法
+func (c *Checker) parseProject(project *Project) {
+	var funcs []*ast.FuncDecl
+	var funcPkgs []string
+
+	err := filepath.Walk(project.Dir, func(filePath string, info fs.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(info.Name()) != ".go" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(project.Dir, filepath.Dir(filePath))
+		if err != nil {
+			return nil
+		}
+		pkgPath := path.Join(c.PkgRoot, project.ScriptRoot, filepath.ToSlash(rel))
+
+		file, err := parser.ParseFile(c.fset, filePath, nil, parser.ParseComments)
+		if err != nil {
+			// 语法错误已在加载时报告
+			return nil
+		}
+
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					td := &_TypeDecl{project: project, pkgPath: pkgPath, file: file, spec: ts}
+					c.typeIdx[pkgPath+"."+ts.Name.Name] = td
+				}
+			case *ast.FuncDecl:
+				if decl.Recv != nil {
+					funcs = append(funcs, decl)
+					funcPkgs = append(funcPkgs, pkgPath)
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		c.report(token.Position{Filename: project.Dir}, SeverityError, CodeLoad, "walk project failed, %s", err)
+		return
+	}
+
+	for i, fd := range funcs {
+		if td, ok := c.typeIdx[funcPkgs[i]+"."+recvTypeName(fd)]; ok {
+			td.methods = append(td.methods, fd)
+		}
+	}
+}
+
+func recvTypeName(fd *ast.FuncDecl) string {
+	if len(fd.Recv.List) <= 0 {
+		return ""
+	}
+	expr := fd.Recv.List[0].Type
+	if starExpr, ok := expr.(*ast.StarExpr); ok {
+		expr = starExpr.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// checkBindings 检查实体与组件原型绑定的脚本类型，与goscr校验解决方案时相同，只检查被原型绑定的类型
+func (c *Checker) checkBindings(solution *dynamic.Solution) {
+	type _Binding struct {
+		ref              string
+		lifecycleMethods []string
+	}
+
+	var bindings []_Binding
+	for _, ref := range c.Entities {
+		bindings = append(bindings, _Binding{ref: ref, lifecycleMethods: goscr.EntityLifecycleMethods})
+	}
+	for _, ref := range c.Components {
+		bindings = append(bindings, _Binding{ref: ref, lifecycleMethods: goscr.ComponentLifecycleMethods})
+	}
+
+	for _, binding := range bindings {
+		idx := strings.LastIndexByte(binding.ref, '.')
+		if idx < 0 {
+			c.report(token.Position{Filename: binding.ref}, SeverityError, CodeUnresolvedScript, "incorrect script %q format", binding.ref)
+			continue
+		}
+
+		td, ok := c.typeIdx[binding.ref]
+		if !ok {
+			c.report(token.Position{Filename: binding.ref}, SeverityError, CodeUnresolvedScript, "script %s.%s not found", binding.ref[:idx], binding.ref[idx+1:])
+			continue
+		}
+
+		c.checkType(solution, td, binding.lifecycleMethods)
+	}
+}
+
+// checkType 检查原型绑定的脚本类型
+func (c *Checker) checkType(solution *dynamic.Solution, td *_TypeDecl, lifecycleMethods []string) {
+	ts := td.spec
+	pos := c.fset.Position(ts.Pos())
+
+	thisExpr, err := bindThisExpr(ts)
+	if err != nil {
+		c.report(pos, SeverityError, CodeBindMode, "type %s can't be bound, %s", ts.Name.Name, err)
+		return
+	}
+
+	n := len(c.diags)
+	c.checkThis(solution, td, thisExpr)
+
+	script := solution.Package(td.pkgPath).Ident(ts.Name.Name)
+
+	if script == nil || script.BindMode == dynamic.None {
+		if n == len(c.diags) && !c.failed[td.project] {
+			c.report(pos, SeverityError, CodeBindMode, "type %s is bound by a prototype but was not recognized as a bindable script", ts.Name.Name)
+		}
+	}
+
+	if script != nil && script.BindMode != dynamic.None && script.MethodBinder == nil && !c.failed[td.project] {
+		c.report(pos, SeverityError, CodeBindMode, "type %s method binder not compiled", ts.Name.Name)
+	}
+
+	for _, fd := range td.methods {
+		c.checkMethod(fd, lifecycleMethods)
+	}
+}
+
+// bindThisExpr 获取绑定的This类型表达式，结构体的第一个字段或无参函数的第一个返回值，必须为*pkg.Type
+func bindThisExpr(ts *ast.TypeSpec) (*ast.SelectorExpr, error) {
+	if ts.TypeParams != nil && len(ts.TypeParams.List) > 0 {
+		return nil, errors.New("generic types are not supported")
+	}
+
+	var thisField *ast.Field
+
+	switch ty := ts.Type.(type) {
+	case *ast.StructType:
+		if ty.Fields == nil || len(ty.Fields.List) <= 0 {
+			return nil, errors.New("struct bind mode requires the first field to be *pkg.Type")
+		}
+		thisField = ty.Fields.List[0]
+	case *ast.FuncType:
+		if ty.Params != nil && len(ty.Params.List) > 0 {
+			return nil, errors.New("func bind mode requires no parameters")
+		}
+		if ty.Results == nil || len(ty.Results.List) <= 0 {
+			return nil, errors.New("func bind mode requires the first result to be *pkg.Type")
+		}
+		thisField = ty.Results.List[0]
+	default:
+		return nil, errors.New("only struct and func types can be bound")
+	}
+
+	starExpr, ok := thisField.Type.(*ast.StarExpr)
+	if !ok {
+		return nil, fmt.Errorf("this type %s must be a pointer", types.ExprString(thisField.Type))
+	}
+
+	selExpr, ok := starExpr.X.(*ast.SelectorExpr)
+	if !ok {
+		return nil, fmt.Errorf("this type %s must be declared in another package", types.ExprString(thisField.Type))
+	}
+
+	return selExpr, nil
+}
+
+// checkThis 检查This类型，需要在脚本包或符号表中声明，导入包名需要与包路径最后一个元素一致或显式指定
+func (c *Checker) checkThis(solution *dynamic.Solution, td *_TypeDecl, thisExpr *ast.SelectorExpr) {
+	pos := c.fset.Position(thisExpr.Pos())
+
+	pkgIdent, ok := thisExpr.X.(*ast.Ident)
+	if !ok {
+		c.report(pos, SeverityError, CodeUnresolvedThis, "this type %s can't be resolved", types.ExprString(thisExpr))
+		return
+	}
+
+	// 与ScriptLib.Load相同，只按显式包名或包路径最后一个元素匹配导入
+	idx := slices.IndexFunc(td.file.Imports, func(spec *ast.ImportSpec) bool {
+		if spec.Name != nil {
+			return spec.Name.Name == pkgIdent.Name
+		}
+		return path.Base(strings.Trim(spec.Path.Value, `"`)) == pkgIdent.Name
+	})
+	if idx < 0 {
+		c.report(pos, SeverityError, CodeUnresolvedThis, "this type %s can't be resolved, import its package with an explicit name %q", types.ExprString(thisExpr), pkgIdent.Name)
+		return
+	}
+	pkgPath := strings.Trim(td.file.Imports[idx].Path.Value, `"`)
+	name := thisExpr.Sel.Name
+
+	if solution.Package(pkgPath) != nil || c.isScriptPkg(pkgPath) {
+		if _, ok := c.typeIdx[pkgPath+"."+name]; !ok {
+			c.report(pos, SeverityError, CodeUnresolvedThis, "this type %s.%s not found in script packages", pkgPath, name)
+		}
+		return
+	}
+
+	for _, symbols := range []map[string]map[string]reflect.Value{stdlib.Symbols, fwlib.Symbols} {
+		for key, pkgSymbols := range symbols {
+			if path.Dir(key) != pkgPath {
+				continue
+			}
+			if _, ok := pkgSymbols[name]; ok {
+				return
+			}
+		}
+	}
+
+	c.report(pos, SeverityError, CodeUnresolvedThis, "this type %s.%s not found in script packages or symbol tables", pkgPath, name)
+}
+
+func (c *Checker) isScriptPkg(pkgPath string) bool {
+	return slices.ContainsFunc(c.Projects, func(project *Project) bool {
+		scriptPath := path.Join(c.PkgRoot, project.ScriptRoot)
+		return pkgPath == scriptPath || strings.HasPrefix(pkgPath, scriptPath+"/")
+	})
+}
+
+// checkMethod 检查生命周期方法与热更新方法签名
+func (c *Checker) checkMethod(fd *ast.FuncDecl, lifecycleMethods []string) {
+	pos := c.fset.Position(fd.Name.Pos())
+	params, results := fieldTypes(fd.Type.Params), fieldTypes(fd.Type.Results)
+
+	switch name := fd.Name.Name; {
+	case slices.Contains(lifecycleMethods, name):
+		if len(params) != 0 || len(results) != 0 {
+			c.report(pos, SeverityError, CodeLifecycleSignature, "lifecycle method %s must have signature func()", name)
+		}
+	case name == "OnBeforeHotfix":
+		if len(params) != 0 || len(results) != 1 || !isAnyExpr(results[0]) {
+			c.report(pos, SeverityError, CodeLifecycleSignature, "hotfix method %s must have signature func() any", name)
+		}
+	case name == "OnAfterHotfix":
+		if len(params) != 1 || len(results) != 0 || !isAnyExpr(params[0]) {
+			c.report(pos, SeverityError, CodeLifecycleSignature, "hotfix method %s must have signature func(any)", name)
+		}
+	}
+}
+
+func fieldTypes(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var exprs []ast.Expr
+	for _, field := range fields.List {
+		for range max(len(field.Names), 1) {
+			exprs = append(exprs, field.Type)
+		}
+	}
+	return exprs
+}
+
+func isAnyExpr(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name == "any"
+	case *ast.InterfaceType:
+		return expr.Methods == nil || len(expr.Methods.List) <= 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,26 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+// Package main implements the goscrcheck script project checker.
+/*
+Package main 实现 goscrcheck 命令，使用与 goscr 相同的 ScriptLib.Load/Compile 逻辑
+与 fwlib 符号表加载脚本工程，检查编译错误，以及实体与组件原型绑定的脚本的 This 类型、
+绑定模式与生命周期方法签名，并输出可供 CI 与编辑器解析的诊断信息。
+*/
+package main
@@ -0,0 +1,135 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git.golaxy.org/scaffold/addins/goscr/dynamic"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func main() {
+	cmd := &cobra.Command{
+		Short: "Static compatibility checker for goscr script projects.",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			viper.BindPFlags(cmd.Flags())
+
+			{
+				projects := viper.GetStringSlice("projects")
+				if len(projects) <= 0 {
+					log.Panic("[--projects] value cannot be empty")
+				}
+				for _, project := range projects {
+					_, dir := parseProject(project)
+					info, err := os.Stat(dir)
+					if err != nil {
+						log.Panicf("[--projects] directory %q is invalid: %s", dir, err)
+					}
+					if !info.IsDir() {
+						log.Panicf("[--projects] %q is not a directory", dir)
+					}
+				}
+			}
+
+			{
+				format := viper.GetString("format")
+				switch format {
+				case "text", "json":
+					break
+				default:
+					log.Panicf("[--format] value must be text or json, but got %q", format)
+				}
+			}
+		},
+		Run: run,
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd: true,
+		},
+	}
+	cmd.Flags().String("pkg_root", "", "Package root path, same as goscr option PkgRoot.")
+	cmd.Flags().StringSlice("projects", nil, "Script project list in load order, each item is <script_root>=<dir> or <dir>, the script root defaults to the directory name.")
+	cmd.Flags().StringSlice("allow", nil, "Symbol policy allow rules, the projects are sandboxed if any allow or deny rule is set.")
+	cmd.Flags().StringSlice("deny", nil, "Symbol policy deny rules, the projects are sandboxed if any allow or deny rule is set.")
+	cmd.Flags().StringSlice("entities", nil, "Scripts bound by entity prototypes, each item is <script_pkg>.<script_ident>, same as goscr.EntityScript.")
+	cmd.Flags().StringSlice("components", nil, "Scripts bound by component prototypes, each item is <script_pkg>.<script_ident>, same as goscr.ComponentScript.")
+	cmd.Flags().String("format", "text", "Diagnostic output format (text/json).")
+
+	if err := cmd.Execute(); err != nil {
+		log.Panic(err)
+	}
+}
+
+func parseProject(project string) (string, string) {
+	scriptRoot, dir, ok := strings.Cut(project, "=")
+	if !ok {
+		dir = project
+		scriptRoot = filepath.Base(filepath.Clean(dir))
+	}
+	return filepath.ToSlash(scriptRoot), dir
+}
+
+func run(*cobra.Command, []string) {
+	checker := &Checker{
+		PkgRoot:    viper.GetString("pkg_root"),
+		Entities:   viper.GetStringSlice("entities"),
+		Components: viper.GetStringSlice("components"),
+	}
+
+	for _, project := range viper.GetStringSlice("projects") {
+		scriptRoot, dir := parseProject(project)
+		checker.Projects = append(checker.Projects, &Project{ScriptRoot: scriptRoot, Dir: dir})
+	}
+
+	allow, deny := viper.GetStringSlice("allow"), viper.GetStringSlice("deny")
+	if len(allow) > 0 || len(deny) > 0 {
+		checker.Policy = &dynamic.SymbolPolicy{Allow: allow, Deny: deny}
+	}
+
+	diags := checker.Check()
+
+	switch viper.GetString("format") {
+	case "json":
+		if diags == nil {
+			diags = []Diagnostic{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diags); err != nil {
+			log.Panic(err)
+		}
+	default:
+		for _, diag := range diags {
+			fmt.Println(diag.String())
+		}
+	}
+
+	for _, diag := range diags {
+		if diag.Severity == SeverityError {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+/*
+ * This file is part of Golaxy Distributed Service Development Framework.
+ *
+ * Golaxy Distributed Service Development Framework is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 2.1 of the License, or
+ * (at your option) any later version.
+ *
+ * Golaxy Distributed Service Development Framework is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with Golaxy Distributed Service Development Framework. If not, see <http://www.gnu.org/licenses/>.
+ *
+ * Copyright (c) 2024 pangdogs.
+ */
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+type GDScriptParamDecl struct {
+	Name   string
+	Type   string
+	TypeID string
+}
+
+type GDScriptOpDecl struct {
+	Name   string
+	Signal string
+	Params []GDScriptParamDecl
+	Args   string
+	Types  string
+	GoDecl string
+}
+
+type GDScriptPropDecl struct {
+	Name string
+	Ops  []GDScriptOpDecl
+}
+
+func genGDScriptCode(outDir, outName string, props []*Prop) {
+	propDecls := make([]GDScriptPropDecl, 0, len(props))
+
+	for _, prop := range props {
+		propDecl := GDScriptPropDecl{Name: prop.Name + "Sync"}
+
+		for _, op := range prop.Ops {
+			if gdscriptSnakeCase(op.Name) == "snapshot" {
+				log.Panicf("prop %q op %q conflicts with the generated snapshot signal", prop.Name, op.Name)
+			}
+
+			opDecl := GDScriptOpDecl{
+				Name:   op.Name,
+				Signal: gdscriptSnakeCase(op.Name) + "_applied",
+				GoDecl: op.Decl,
+			}
+
+			var args, types []string
+
+			for i, param := range op.Params {
+				gdType, gdTypeID := gdscriptParamType(param.Type)
+				opDecl.Params = append(opDecl.Params, GDScriptParamDecl{
+					Name:   gdscriptParamName(param.Name),
+					Type:   gdType,
+					TypeID: gdTypeID,
+				})
+				args = append(args, fmt.Sprintf("args[%d]", i))
+				types = append(types, opDecl.Params[i].TypeID)
+			}
+
+			opDecl.Args = strings.Join(args, ", ")
+			opDecl.Types = strings.Join(types, ", ")
+
+			propDecl.Ops = append(propDecl.Ops, opDecl)
+		}
+
+		propDecls = append(propDecls, propDecl)
+	}
+
+	const tmpl = `{{.Comment}}
+#
+# Property mirrors replaying ops synchronized by addins/propview, requires godot/propview.
+# Extend the mirror classes and override the _on_<Op> methods to apply ops to the client state,
+# and _on_snapshot to replace the client state with the full state sent on request.
+{{range .Props}}
+class {{.Name}}:
+	extends GolaxyPropMirror
+
+	signal snapshot_applied(state: Variant)
+{{- range .Ops}}
+	signal {{.Signal}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Name}}: {{$p.Type}}{{end}})
+{{- end}}
+{{range .Ops}}
+	const OP_{{.Name}} := "{{.Name}}"
+{{- end}}
+
+	func _apply_op(op: String, args: Array) -> int:
+		match op:
+{{- range .Ops}}
+			OP_{{.Name}}:
+				var err := _check_args(args, [{{.Types}}])
+				if err != OK:
+					return err
+				_on_{{.Name}}({{.Args}})
+				{{.Signal}}.emit({{.Args}})
+				return OK
+{{- end}}
+		return ERR_METHOD_NOT_FOUND
+
+	func _apply_snapshot(args: Array) -> int:
+		var err := _check_args(args, [TYPE_NIL])
+		if err != OK:
+			return err
+		_on_snapshot(args[0])
+		snapshot_applied.emit(args[0])
+		return OK
+
+	# full state of the property, decoded from the variant sent by propview
+	func _on_snapshot(state: Variant) -> void:
+		pass
+{{range .Ops}}
+	# {{.GoDecl}}
+	func _on_{{.Name}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Name}}: {{$p.Type}}{{end}}) -> void:
+		pass
+{{end}}
+{{- end}}`
+
+	type TmplArgs struct {
+		Comment string
+		Props   []GDScriptPropDecl
+	}
+
+	args := &TmplArgs{
+		Comment: fmt.Sprintf("# Code generated by %s %s; DO NOT EDIT.", strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0])), strings.Join(os.Args[1:], " ")),
+		Props:   propDecls,
+	}
+
+	t := template.Must(template.New("code").Parse(tmpl))
+
+	os.MkdirAll(outDir, os.ModePerm)
+
+	file, err := os.OpenFile(filepath.Join(outDir, outName+".sync.gen.gd"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		log.Panic(err)
+	}
+	defer file.Close()
+
+	err = t.Execute(file, args)
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+func gdscriptParamType(goType string) (string, string) {
+	goType = strings.TrimSpace(goType)
+
+	switch goType {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune", "uintptr":
+		return "int", "TYPE_INT"
+	case "float32", "float64":
+		return "float", "TYPE_FLOAT"
+	case "bool":
+		return "bool", "TYPE_BOOL"
+	case "string":
+		return "String", "TYPE_STRING"
+	case "[]byte", "[]uint8":
+		return "PackedByteArray", "TYPE_PACKED_BYTE_ARRAY"
+	}
+
+	switch {
+	case strings.HasPrefix(goType, "[]"), strings.HasPrefix(goType, "..."):
+		return "Array", "TYPE_ARRAY"
+	case strings.HasPrefix(goType, "map["):
+		return "Dictionary", "TYPE_DICTIONARY"
+	default:
+		return "Variant", "TYPE_NIL"
+	}
+}
+
+var gdscriptReservedNames = map[string]struct{}{
+	"if": {}, "elif": {}, "else": {}, "for": {}, "while": {}, "match": {}, "break": {}, "continue": {}, "pass": {},
+	"return": {}, "class": {}, "class_name": {}, "extends": {}, "is": {}, "in": {}, "as": {}, "self": {}, "signal": {},
+	"func": {}, "static": {}, "const": {}, "enum": {}, "var": {}, "breakpoint": {}, "preload": {}, "await": {},
+	"yield": {}, "assert": {}, "void": {}, "and": {}, "or": {}, "not": {}, "true": {}, "false": {}, "null": {},
+	"args": {}, "op": {}, "err": {}, "name": {}, "entity_id": {}, "revision": {}, "reset": {}, "changed": {}, "desynced": {},
+}
+
+func gdscriptParamName(name string) string {
+	if _, ok := gdscriptReservedNames[name]; ok {
+		return name + "_"
+	}
+	return name
+}
+
+func gdscriptSnakeCase(name string) string {
+	var sb strings.Builder
+	rs := []rune(name)
+
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
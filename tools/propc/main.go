@@ -32,6 +32,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -47,7 +48,15 @@ func main() {
 		Short: "Property synchronization code generator.",
 		PreRun: func(cmd *cobra.Command, args []string) {
 			viper.BindPFlags(cmd.Flags())
-			{
+			if packageDir := viper.GetString("package_dir"); packageDir != "" {
+				info, err := os.Stat(packageDir)
+				if err != nil {
+					log.Panicf("[--package_dir] directory %q is invalid: %s", packageDir, err)
+				}
+				if !info.IsDir() {
+					log.Panicf("[--package_dir] %q is not a directory", packageDir)
+				}
+			} else {
 				declFile := viper.GetString("decl_file")
 				if declFile == "" {
 					log.Panic("[--decl_file] value cannot be empty")
@@ -63,155 +72,350 @@ func main() {
 		},
 	}
 	cmd.Flags().String("decl_file", os.Getenv("GOFILE"), "Property declaration file (.go).")
+	cmd.Flags().String("package_dir", "", "Scan all declaration files (.go) in the package directory and generate a single props.sync.gen.go, takes precedence over [--decl_file].")
+	cmd.Flags().String("gdscript_out", "", "Output directory of Godot client property mirror scripts (.gd), skipped if empty.")
 
 	if err := cmd.Execute(); err != nil {
 		log.Panic(err)
 	}
 }
 
-func run(*cobra.Command, []string) {
-	fset, fast, fdata := loadDeclFile()
+var qualifiedIdentRegexp = regexp.MustCompile(`([A-Za-z_]\w*)\.[A-Za-z_]\w*`)
 
-	type Op struct {
-		Name          string
-		Args          string
-		Decl          string
-		Call          string
-		CallResults   string
-		ReturnResults string
-		Types         map[string]any
-	}
+type DeclFile struct {
+	Path string
+	AST  *ast.File
+	Data []byte
+}
 
-	type Prop struct {
-		Name string
-		Ops  []Op
-	}
+type Param struct {
+	Name    string
+	Type    string
+	ArgType string
+}
+
+type Op struct {
+	Name          string
+	Args          string
+	Decl          string
+	Call          string
+	CallResults   string
+	ReturnResults string
+	Types         map[string]any
+	Params        []Param
+	ApplyArgs     string
+	Validates     []string
+	ErrResult     string
+	Visibility    string
+}
+
+type Prop struct {
+	Name          string
+	Ops           []Op
+	Validate      string
+	StateType     string
+	Readonly      []string
+	ReadonlyDecls []string
+	ReadonlyTypes map[string]any
+	Filter        string
+	HasPolicy     bool
+}
+
+func run(*cobra.Command, []string) {
+	fset, declFiles := loadDeclFiles()
 
 	props := generic.UnorderedSliceMap[string, *Prop]{}
 
-	ast.Inspect(fast, func(node ast.Node) bool {
-		ts, ok := node.(*ast.TypeSpec)
-		if !ok {
-			return true
-		}
+	for _, declFile := range declFiles {
+		fast, fdata := declFile.AST, declFile.Data
 
-		atti := getAtti(fset, fast, node)
+		ast.Inspect(fast, func(node ast.Node) bool {
+			ts, ok := node.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
 
-		if !atti.Has("sync") {
-			return true
-		}
+			atti := getAtti(fset, fast, node)
 
-		if b, err := strconv.ParseBool(atti.Get("sync")); err != nil || !b {
-			return true
-		}
+			if !atti.Has("sync") {
+				return true
+			}
 
-		props.Add(ts.Name.String(), &Prop{Name: ts.Name.String()})
-		return true
-	})
+			if b, err := strconv.ParseBool(atti.Get("sync")); err != nil || !b {
+				return true
+			}
 
-	ast.Inspect(fast, func(node ast.Node) bool {
-		fd, ok := node.(*ast.FuncDecl)
-		if !ok {
-			return true
-		}
+			prop := &Prop{
+				Name:          ts.Name.String(),
+				Validate:      atti.Get("validate"),
+				Filter:        atti.Get("filter"),
+				StateType:     embeddedStateType(ts, fset, fdata),
+				ReadonlyTypes: map[string]any{},
+			}
 
-		atti := getAtti(fset, fast, node)
+			for _, v := range atti["readonly"] {
+				for _, getter := range strings.Split(v, ",") {
+					if getter = strings.TrimSpace(getter); getter != "" && !slices.Contains(prop.Readonly, getter) {
+						prop.Readonly = append(prop.Readonly, getter)
+					}
+				}
+			}
 
-		if !atti.Has("sync") {
+			props.Add(ts.Name.String(), prop)
 			return true
-		}
+		})
+	}
 
-		if b, err := strconv.ParseBool(atti.Get("sync")); err != nil || !b {
-			return true
-		}
+	// 扫描包目录时同步类型与操作可以分布在不同文件中，无法归属的同步操作视为错误，单文件模式下跳过
+	strict := viper.GetString("package_dir") != ""
 
-		if fd.Recv == nil || len(fd.Recv.List) <= 0 {
-			return true
-		}
+	for _, declFile := range declFiles {
+		fast, fdata := declFile.AST, declFile.Data
 
-		starExpr, ok := fd.Recv.List[0].Type.(*ast.StarExpr)
-		if !ok {
-			return true
-		}
+		ast.Inspect(fast, func(node ast.Node) bool {
+			fd, ok := node.(*ast.FuncDecl)
+			if !ok {
+				return true
+			}
 
-		ident, ok := starExpr.X.(*ast.Ident)
-		if !ok {
-			return true
-		}
+			atti := getAtti(fset, fast, node)
 
-		prop, ok := props.Get(ident.Name)
-		if !ok {
-			return true
-		}
+			if !atti.Has("sync") {
+				return true
+			}
 
-		op := Op{}
+			if b, err := strconv.ParseBool(atti.Get("sync")); err != nil || !b {
+				return true
+			}
 
-		op.Name = fd.Name.String()
+			if fd.Recv == nil || len(fd.Recv.List) <= 0 {
+				if !strict {
+					return true
+				}
+				log.Panicf("%s: annotated func %s is not a method of a sync property", fset.Position(fd.Pos()), fd.Name)
+			}
 
-		paramNames, paramDecls, paramTypes, usedNames := expandFieldList(fd.Type.Params, fset, fdata, "p", nil)
-		resultNames, resultDecls, _, _ := expandFieldList(fd.Type.Results, fset, fdata, "r", usedNames)
+			starExpr, ok := fd.Recv.List[0].Type.(*ast.StarExpr)
+			if !ok {
+				if !strict {
+					return true
+				}
+				log.Panicf("%s: annotated method %s must have a pointer receiver", fset.Position(fd.Pos()), fd.Name)
+			}
 
-		op.Args = strings.Join(paramNames, ", ")
+			ident, ok := starExpr.X.(*ast.Ident)
+			if !ok {
+				if !strict {
+					return true
+				}
+				log.Panicf("%s: annotated method %s has an unsupported receiver type", fset.Position(fd.Pos()), fd.Name)
+			}
 
-		{
-			var sig strings.Builder
-			sig.WriteString(fd.Name.String())
-			sig.WriteString("(")
-			sig.WriteString(strings.Join(paramDecls, ", "))
-			sig.WriteString(")")
+			prop, ok := props.Get(ident.Name)
+			if !ok {
+				if !strict {
+					return true
+				}
+				log.Panicf("%s: receiver type %s of annotated method %s is not a sync property", fset.Position(fd.Pos()), ident.Name, fd.Name)
+			}
+
+			op := Op{}
+
+			op.Name = fd.Name.String()
 
-			if len(resultDecls) > 0 {
-				sig.WriteString(" (")
-				sig.WriteString(strings.Join(resultDecls, ", "))
+			paramNames, paramDecls, paramTypes, usedNames := expandFieldList(fd.Type.Params, fset, fdata, "p", nil)
+			resultNames, resultDecls, resultTypes, _ := expandFieldList(fd.Type.Results, fset, fdata, "r", usedNames)
+
+			op.Args = strings.Join(paramNames, ", ")
+
+			{
+				var sig strings.Builder
+				sig.WriteString(fd.Name.String())
+				sig.WriteString("(")
+				sig.WriteString(strings.Join(paramDecls, ", "))
 				sig.WriteString(")")
+
+				if len(resultDecls) > 0 {
+					sig.WriteString(" (")
+					sig.WriteString(strings.Join(resultDecls, ", "))
+					sig.WriteString(")")
+				}
+
+				op.Decl = sig.String()
 			}
 
-			op.Decl = sig.String()
-		}
+			{
+				op.Call = fd.Name.String() + "("
+				op.Call += strings.Join(paramNames, ", ")
+				if len(paramTypes) > 0 && strings.HasPrefix(paramTypes[len(paramTypes)-1], "...") {
+					op.Call += "..."
+				}
+				op.Call += ")"
+			}
 
-		{
-			op.Call = fd.Name.String() + "("
-			op.Call += strings.Join(paramNames, ", ")
-			op.Call += ")"
-		}
+			{
+				var validates []string
 
-		op.ReturnResults = "return"
+				if prop.Validate != "" {
+					validates = append(validates, fmt.Sprintf("%s(%q", prop.Validate, op.Name))
+					if op.Args != "" {
+						validates[0] += ", " + op.Args
+					}
+					validates[0] += ")"
+				}
 
-		if len(resultNames) > 0 {
-			op.ReturnResults += " "
+				if validate := atti.Get("validate"); validate != "" {
+					validates = append(validates, validate+strings.TrimPrefix(op.Call, op.Name))
+				}
 
-			for i, name := range resultNames {
-				if i > 0 {
-					op.ReturnResults += ", "
-					op.CallResults += ", "
+				if len(validates) > 0 {
+					if len(resultTypes) <= 0 || strings.TrimSpace(resultTypes[len(resultTypes)-1]) != "error" {
+						log.Panicf("%s: validated method %s must return error as the last result", fset.Position(fd.Pos()), fd.Name)
+					}
+					op.Validates = validates
+					op.ErrResult = resultNames[len(resultNames)-1]
 				}
-				op.ReturnResults += name
-				op.CallResults += name
 			}
 
-			op.CallResults += " = "
-		}
+			switch visible := atti.Get("visible"); visible {
+			case "", "all":
+			case "owner":
+				op.Visibility = "propview.VisibleOwner"
+			case "server":
+				op.Visibility = "propview.VisibleServer"
+			default:
+				log.Panicf("%s: method %s has invalid visible %q, must be all, owner or server", fset.Position(fd.Pos()), fd.Name, visible)
+			}
 
-		{
-			op.Types = make(map[string]any)
+			op.ReturnResults = "return"
 
-			for _, name := range paramTypes {
-				op.Types[name] = struct{}{}
+			if len(resultNames) > 0 {
+				op.ReturnResults += " "
+
+				for i, name := range resultNames {
+					if i > 0 {
+						op.ReturnResults += ", "
+						op.CallResults += ", "
+					}
+					op.ReturnResults += name
+					op.CallResults += name
+				}
+
+				op.CallResults += " = "
+			}
+
+			{
+				op.Types = make(map[string]any)
+
+				for _, name := range paramTypes {
+					op.Types[name] = struct{}{}
+				}
+			}
+
+			{
+				var applyArgs []string
+
+				for i := range paramNames {
+					param := Param{Name: paramNames[i], Type: paramTypes[i], ArgType: paramTypes[i]}
+					applyArg := fmt.Sprintf("arg%d", i)
+
+					if variadic, ok := strings.CutPrefix(param.Type, "..."); ok {
+						param.ArgType = "[]" + variadic
+						applyArg += "..."
+					}
+
+					op.Params = append(op.Params, param)
+					applyArgs = append(applyArgs, applyArg)
+				}
+
+				op.ApplyArgs = strings.Join(applyArgs, ", ")
+			}
+
+			prop.Ops = append(prop.Ops, op)
+
+			return true
+		})
+	}
+
+	for _, declFile := range declFiles {
+		fdata := declFile.Data
+
+		for _, decl := range declFile.AST.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv == nil || len(fd.Recv.List) <= 0 {
+				continue
+			}
+
+			recvType := fd.Recv.List[0].Type
+			if starExpr, ok := recvType.(*ast.StarExpr); ok {
+				recvType = starExpr.X
+			}
+
+			ident, ok := recvType.(*ast.Ident)
+			if !ok {
+				continue
+			}
+
+			prop, ok := props.Get(ident.Name)
+			if !ok || !slices.Contains(prop.Readonly, fd.Name.Name) {
+				continue
+			}
+
+			if slices.ContainsFunc(prop.Ops, func(op Op) bool { return op.Name == fd.Name.Name }) {
+				log.Panicf("%s: readonly getter %s of %s must not be a sync op", fset.Position(fd.Pos()), fd.Name, prop.Name)
+			}
+
+			start := fset.Position(fd.Type.Params.Pos()).Offset
+			end := fset.Position(fd.Type.End()).Offset
+			prop.ReadonlyDecls = append(prop.ReadonlyDecls, fd.Name.Name+string(fdata[start:end]))
+
+			_, _, paramTypes, _ := expandFieldList(fd.Type.Params, fset, fdata, "p", nil)
+			_, _, resultTypes, _ := expandFieldList(fd.Type.Results, fset, fdata, "r", nil)
+
+			for _, t := range append(paramTypes, resultTypes...) {
+				prop.ReadonlyTypes[t] = struct{}{}
 			}
 		}
+	}
 
-		prop.Ops = append(prop.Ops, op)
+	props.Each(func(_ string, prop *Prop) {
+		prop.HasPolicy = prop.Filter != "" || slices.ContainsFunc(prop.Ops, func(op Op) bool { return op.Visibility != "" })
 
-		return true
+		var notFound []string
+
+		for _, getter := range prop.Readonly {
+			if slices.ContainsFunc(prop.ReadonlyDecls, func(decl string) bool { return strings.HasPrefix(decl, getter+"(") }) {
+				continue
+			}
+
+			// 嵌入的PropT提升的只读方法
+			if decl, ok := promotedGetterDecl(getter, prop.StateType); ok {
+				prop.ReadonlyDecls = append(prop.ReadonlyDecls, decl)
+				if prop.StateType != "" {
+					prop.ReadonlyTypes[prop.StateType] = struct{}{}
+				}
+				continue
+			}
+
+			notFound = append(notFound, getter)
+		}
+
+		if len(notFound) > 0 {
+			log.Panicf("readonly getters %v of %s not found", notFound, prop.Name)
+		}
 	})
 
 	var imports []string
 
-	for _, is := range fast.Imports {
-		var buf bytes.Buffer
-		printer.Fprint(&buf, fset, is)
+	for _, declFile := range declFiles {
+		for _, is := range declFile.AST.Imports {
+			var buf bytes.Buffer
+			printer.Fprint(&buf, fset, is)
 
-		imports = append(imports, buf.String())
+			if !slices.Contains(imports, buf.String()) {
+				imports = append(imports, buf.String())
+			}
+		}
 	}
 
 	if !slices.ContainsFunc(imports, func(i string) bool {
@@ -225,21 +429,28 @@ func run(*cobra.Command, []string) {
 		imports = append(imports, `propview "git.golaxy.org/scaffold/addins/propview"`)
 	}
 
+	if !slices.Contains(imports, `"reflect"`) {
+		imports = append(imports, `"reflect"`)
+	}
+
 	opImports := map[string]struct{}{
 		"propview": {},
+		"reflect":  {},
 	}
 
 	props.Each(func(_ string, prop *Prop) {
 		for _, op := range prop.Ops {
 			for t := range op.Types {
-				if strings.Contains(t, ".") {
-					pkg := strings.Split(strings.Trim(t, "*"), ".")
-					if len(pkg) > 0 {
-						opImports[pkg[0]] = struct{}{}
-					}
+				for _, m := range qualifiedIdentRegexp.FindAllStringSubmatch(t, -1) {
+					opImports[m[1]] = struct{}{}
 				}
 			}
 		}
+		for t := range prop.ReadonlyTypes {
+			for _, m := range qualifiedIdentRegexp.FindAllStringSubmatch(t, -1) {
+				opImports[m[1]] = struct{}{}
+			}
+		}
 	})
 
 	imports = slices.DeleteFunc(imports, func(i string) bool {
@@ -298,15 +509,76 @@ func (ps *{{.Name}}Sync) Save(service string) error {
 func (ps *{{.Name}}Sync) Managed() propview.IProp {
 	return &ps.{{.Name}}
 }
+{{- if .ReadonlyDecls}}
+
+type {{.Name}}ReadOnly interface {
+	{{- range .ReadonlyDecls}}
+	{{.}}
+	{{- end}}
+}
+
+func (ps *{{.Name}}Sync) ReadOnly() {{.Name}}ReadOnly {
+	return &ps.{{.Name}}
+}
+{{- end}}
+{{- if .HasPolicy}}
+
+func (ps *{{.Name}}Sync) SyncPolicy() *propview.SyncPolicy {
+	return &propview.SyncPolicy{
+		Visibility: map[string]propview.Visibility{
+			{{- range .Ops}}
+			{{- if .Visibility}}
+			"{{.Name}}": {{.Visibility}},
+			{{- end}}
+			{{- end}}
+		},
+		{{- if .Filter}}
+		Filter: ps.{{.Name}}.{{.Filter}},
+		{{- end}}
+	}
+}
+{{- end}}
 
 {{- $propName := .Name}}
 {{range .Ops}}
+{{- $op := .}}
 func (ps *{{$propName}}Sync) {{.Decl}} {
+	{{- range .Validates}}
+	if validateErr := ps.{{$propName}}.{{.}}; validateErr != nil {
+		{{$op.ErrResult}} = validateErr
+		{{$op.ReturnResults}}
+	}
+	{{- end}}
 	{{.CallResults}}ps.{{$propName}}.{{.Call}}
+	{{- if $op.ErrResult}}
+	if {{$op.ErrResult}} != nil {
+		{{$op.ReturnResults}}
+	}
+	{{- end}}
 	propview.UnsafePropSync(ps).Sync(propview.UnsafeProp(&ps.{{$propName}}).IncrRevision(), "{{.Name}}", {{.Args}})
 	{{.ReturnResults}}
 }
 {{end}}
+func (ps *{{$propName}}Sync) ApplyOp(op string, args []reflect.Value) error {
+	switch op {
+	{{- range .Ops}}
+	case "{{.Name}}":
+		if len(args) != {{len .Params}} {
+			return propview.ErrMethodParameterCountMismatch
+		}
+		{{- range $i, $p := .Params}}
+		arg{{$i}}, err := propview.ConvertOpArg[{{$p.ArgType}}](op, {{$i}}, "{{$p.Name}}", args[{{$i}}])
+		if err != nil {
+			return err
+		}
+		{{- end}}
+		ps.{{$propName}}.{{.Name}}({{.ApplyArgs}})
+		return nil
+	{{- end}}
+	default:
+		return propview.ErrMethodNotFound
+	}
+}
 {{end}}
 `
 
@@ -319,18 +591,18 @@ func (ps *{{$propName}}Sync) {{.Decl}} {
 
 	args := &TmplArgs{
 		Comment: fmt.Sprintf("// Code generated by %s %s; DO NOT EDIT.", strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0])), strings.Join(os.Args[1:], " ")),
-		Package: fast.Name.Name,
+		Package: declFiles[0].AST.Name.Name,
 		Imports: imports,
 		Props:   props.Values(),
 	}
 
 	t := template.Must(template.New("code").Parse(tmpl))
 
-	declFile := viper.GetString("decl_file")
+	outDir, outName := outputPath()
 
-	os.MkdirAll(filepath.Dir(declFile), os.ModePerm)
+	os.MkdirAll(outDir, os.ModePerm)
 
-	file, err := os.OpenFile(filepath.Join(filepath.Dir(declFile), filepath.Base(strings.TrimSuffix(declFile, ".go"))+".sync.gen.go"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	file, err := os.OpenFile(filepath.Join(outDir, outName+".sync.gen.go"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
 	if err != nil {
 		log.Panic(err)
 	}
@@ -341,6 +613,10 @@ func (ps *{{$propName}}Sync) {{.Decl}} {
 		log.Panic(err)
 	}
 
+	if gdscriptOut := viper.GetString("gdscript_out"); gdscriptOut != "" {
+		genGDScriptCode(gdscriptOut, outName, props.Values())
+	}
+
 	props.Each(func(_ string, prop *Prop) {
 		log.Printf("Prop: %s", prop.Name)
 		for _, op := range prop.Ops {
@@ -349,22 +625,65 @@ func (ps *{{$propName}}Sync) {{.Decl}} {
 	})
 }
 
-func loadDeclFile() (*token.FileSet, *ast.File, []byte) {
-	declFile := viper.GetString("decl_file")
+func loadDeclFiles() (*token.FileSet, []DeclFile) {
+	fset := token.NewFileSet()
 
-	fileData, err := ioutil.ReadFile(declFile)
+	packageDir := viper.GetString("package_dir")
+	if packageDir == "" {
+		return fset, []DeclFile{loadDeclFile(fset, viper.GetString("decl_file"))}
+	}
+
+	entries, err := os.ReadDir(packageDir)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	fset := token.NewFileSet()
+	var declFiles []DeclFile
+
+	// ReadDir返回的文件已按名称排序，保证生成结果稳定
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if entry.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") || strings.HasSuffix(name, ".gen.go") {
+			continue
+		}
+
+		declFile := loadDeclFile(fset, filepath.Join(packageDir, name))
+
+		if len(declFiles) > 0 && declFiles[0].AST.Name.Name != declFile.AST.Name.Name {
+			log.Panicf("found packages %s (%s) and %s (%s) in %q", declFiles[0].AST.Name.Name, filepath.Base(declFiles[0].Path), declFile.AST.Name.Name, name, packageDir)
+		}
+
+		declFiles = append(declFiles, declFile)
+	}
+
+	if len(declFiles) <= 0 {
+		log.Panicf("no declaration files found in %q", packageDir)
+	}
+
+	return fset, declFiles
+}
+
+func loadDeclFile(fset *token.FileSet, path string) DeclFile {
+	fileData, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Panic(err)
+	}
 
-	fast, err := parser.ParseFile(fset, declFile, fileData, parser.ParseComments)
+	fast, err := parser.ParseFile(fset, path, fileData, parser.ParseComments)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	return fset, fast, fileData
+	return DeclFile{Path: path, AST: fast, Data: fileData}
+}
+
+func outputPath() (string, string) {
+	if packageDir := viper.GetString("package_dir"); packageDir != "" {
+		return packageDir, "props"
+	}
+	declFile := viper.GetString("decl_file")
+	return filepath.Dir(declFile), filepath.Base(strings.TrimSuffix(declFile, ".go"))
 }
 
 func parseGenAtti(str, atti string) url.Values {
@@ -401,6 +720,59 @@ func getAtti(fset *token.FileSet, fast *ast.File, node ast.Node) url.Values {
 	return url.Values{}
 }
 
+// embeddedStateType 获取同步属性嵌入的PropT的状态类型
+func embeddedStateType(ts *ast.TypeSpec, fset *token.FileSet, fdata []byte) string {
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok || st.Fields == nil {
+		return ""
+	}
+
+	for _, field := range st.Fields.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+
+		indexExpr, ok := field.Type.(*ast.IndexExpr)
+		if !ok {
+			continue
+		}
+
+		var name string
+		switch x := indexExpr.X.(type) {
+		case *ast.Ident:
+			name = x.Name
+		case *ast.SelectorExpr:
+			name = x.Sel.Name
+		}
+		if name != "PropT" {
+			continue
+		}
+
+		start := fset.Position(indexExpr.Index.Pos()).Offset
+		end := fset.Position(indexExpr.Index.End()).Offset
+		return string(fdata[start:end])
+	}
+
+	return ""
+}
+
+// promotedGetterDecl 获取嵌入的PropT提升的只读方法声明
+func promotedGetterDecl(getter, stateType string) (string, bool) {
+	switch getter {
+	case "Revision":
+		return "Revision() int64", true
+	case "ReflectedState":
+		return "ReflectedState() reflect.Value", true
+	case "State":
+		if stateType == "" {
+			return "", false
+		}
+		return "State() " + stateType, true
+	default:
+		return "", false
+	}
+}
+
 func expandFieldList(fields *ast.FieldList, fset *token.FileSet, fdata []byte, unnamedPrefix string, usedNames map[string]struct{}) ([]string, []string, []string, map[string]struct{}) {
 	if usedNames == nil {
 		usedNames = map[string]struct{}{}
@@ -422,13 +794,13 @@ func expandFieldList(fields *ast.FieldList, fset *token.FileSet, fdata []byte, u
 		start := fset.Position(field.Type.Pos()).Offset
 		end := fset.Position(field.Type.End()).Offset
 		typeName := string(fdata[start:end])
-		types = append(types, typeName)
 
 		if len(field.Names) == 0 {
 			unnamedCount++
 			name := uniqueGeneratedName(unnamedPrefix, unnamedCount, usedNames)
 			names = append(names, name)
 			decls = append(decls, name+" "+typeName)
+			types = append(types, typeName)
 			continue
 		}
 
@@ -442,6 +814,7 @@ func expandFieldList(fields *ast.FieldList, fset *token.FileSet, fdata []byte, u
 			}
 			names = append(names, name)
 			decls = append(decls, name+" "+typeName)
+			types = append(types, typeName)
 		}
 	}
 